@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestForcedPasswordResetRevokesAccessTokens(t *testing.T) {
+	ctx := context.Background()
+	a := newTestApp(t)
+	srv := newTestServer(t, a)
+	do(t, http.MethodPost, srv.URL+"/user", testUser)
+
+	bearer := func() string {
+		token, err := a.userService.IssueAccessToken(ctx, "jane")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + token
+	}
+	listKeys := func(auth string) int {
+		return doWithHeader(t, http.MethodGet, srv.URL+"/apikeys", "", "Authorization", auth)
+	}
+
+	before := bearer()
+	if got := listKeys(before); got != http.StatusOK {
+		t.Fatalf("before the reset: got status %d, want %d", got, http.StatusOK)
+	}
+
+	if err := a.userService.ForcePasswordReset(ctx, "jane"); err != nil {
+		t.Fatal(err)
+	}
+	if got := listKeys(before); got != http.StatusUnauthorized {
+		t.Errorf("token issued before the reset: got status %d, want %d", got, http.StatusUnauthorized)
+	}
+	if got := listKeys(bearer()); got != http.StatusOK {
+		t.Errorf("token issued after the reset: got status %d, want %d", got, http.StatusOK)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"petstore/internal/config"
+	"petstore/internal/db"
+	"petstore/internal/mailer"
+	"petstore/internal/metrics"
+	"petstore/internal/repository"
+	"petstore/internal/repository/memory"
+	"petstore/internal/service"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// txMaxAttempts is how often services run a transaction that failed to
+// serialize before giving up.
+const txMaxAttempts = 3
+
+// app holds the repositories and services, wired the same way for the server
+// and the operations commands.
+type app struct {
+	cfg       config.Config
+	metrics   *metrics.Metrics
+	passwords *service.PasswordManager
+
+	tx    repository.TxManager
+	users repository.UserRepository
+
+	petService     service.PetService
+	orderService   service.OrderService
+	userService    service.UserService
+	apiKeyService  service.APIKeyService
+	privacyService service.PrivacyService
+}
+
+// repositories are the data access the app is built on, either on Postgres
+// or in memory.
+type repositories struct {
+	tx       repository.TxManager
+	pets     repository.PetRepository
+	orders   repository.OrderRepository
+	users    repository.UserRepository
+	tokens   repository.TokenRepository
+	apiKeys  repository.APIKeyRepository
+	erasures repository.ErasureRepository
+}
+
+func postgresRepositories(dbConn *sqlx.DB, queryTimeout time.Duration) repositories {
+	return repositories{
+		tx:       repository.NewTxManager(dbConn, sql.LevelSerializable, txMaxAttempts),
+		pets:     repository.NewPetRepository(dbConn, queryTimeout),
+		orders:   repository.NewOrderRepository(dbConn, queryTimeout),
+		users:    repository.NewUserRepository(dbConn, queryTimeout),
+		tokens:   repository.NewTokenRepository(dbConn, queryTimeout),
+		apiKeys:  repository.NewAPIKeyRepository(dbConn, queryTimeout),
+		erasures: repository.NewErasureRepository(dbConn, queryTimeout),
+	}
+}
+
+func memoryRepositories() repositories {
+	store := memory.NewStore()
+	return repositories{
+		tx:       memory.NewTxManager(store),
+		pets:     memory.NewPetRepository(store),
+		orders:   memory.NewOrderRepository(store),
+		users:    memory.NewUserRepository(store),
+		tokens:   memory.NewTokenRepository(store),
+		apiKeys:  memory.NewAPIKeyRepository(store),
+		erasures: memory.NewErasureRepository(store),
+	}
+}
+
+func newApp(cfg config.Config, repos repositories) (*app, error) {
+	a := &app{cfg: cfg, metrics: metrics.New(), tx: repos.tx}
+
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init mailer: %w", err)
+	}
+	a.passwords, err = service.NewPasswordManager(cfg.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init password policy: %w", err)
+	}
+
+	petRepo := repository.InstrumentPetRepository(repos.pets, a.metrics)
+	tokenRepo := repository.InstrumentTokenRepository(repos.tokens, a.metrics)
+	a.users = repository.InstrumentUserRepository(repos.users, a.metrics)
+	orderRepo := repository.InstrumentOrderRepository(repos.orders, a.metrics)
+	apiKeyRepo := repository.InstrumentAPIKeyRepository(repos.apiKeys, a.metrics)
+	erasureRepo := repository.InstrumentErasureRepository(repos.erasures, a.metrics)
+
+	loginThrottler := service.NewLoginThrottler(service.DefaultLoginThrottleConfig)
+	a.petService = service.TracePetService(service.NewPetService(petRepo, repos.tx, a.metrics))
+	a.orderService = service.TraceOrderService(service.NewOrderService(orderRepo, petRepo, a.users, repos.tx, a.metrics))
+	a.userService = service.TraceUserService(
+		service.NewUserService(a.users, tokenRepo, mail, loginThrottler, a.passwords, a.metrics,
+			cfg.Mail.AppBaseURL, cfg.Mail.PasswordResetURL),
+	)
+	a.apiKeyService = service.TraceAPIKeyService(service.NewAPIKeyService(apiKeyRepo, a.users))
+	a.privacyService = service.TracePrivacyService(service.NewPrivacyService(a.users, orderRepo, apiKeyRepo, erasureRepo))
+
+	return a, nil
+}
+
+// errMemoryStorage is returned by commands that would work on data that is
+// gone when they exit.
+var errMemoryStorage = errors.New("this command needs postgres storage, in-memory data does not outlive the command")
+
+// withApp connects to the database for an operations command.
+func (c *cli) withApp(ctx context.Context, fn func(*app) error) error {
+	if c.cfg.Storage == config.StorageMemory {
+		return errMemoryStorage
+	}
+	dbConn, err := db.Connect(ctx, c.cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+
+	a, err := newApp(c.cfg, postgresRepositories(dbConn, c.cfg.Database.QueryTimeout))
+	if err != nil {
+		return err
+	}
+	return fn(a)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"petstore/internal/config"
+	"petstore/internal/db"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCmd(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply, roll back or inspect schema migrations",
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "Apply all pending migrations",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return c.migrateWith(cmd, func(m *db.Migrator) error {
+					return m.Up(cmd.Context())
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "down [N]",
+			Short: "Roll back the last N migrations, 1 by default",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				steps := 1
+				if len(args) == 1 {
+					n, err := strconv.Atoi(args[0])
+					if err != nil || n < 1 {
+						return fmt.Errorf("invalid number of migrations %q", args[0])
+					}
+					steps = n
+				}
+				return c.migrateWith(cmd, func(m *db.Migrator) error {
+					return m.Down(cmd.Context(), steps)
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "to VERSION",
+			Short: "Migrate up or down to VERSION",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := strconv.ParseUint(args[0], 10, 32)
+				if err != nil || version == 0 {
+					return fmt.Errorf("invalid version %q, use \"migrate down\" to roll back the first migration", args[0])
+				}
+				return c.migrateWith(cmd, func(m *db.Migrator) error {
+					return m.To(cmd.Context(), uint(version))
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "Print the applied and the latest schema version",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, _ []string) error {
+				return c.withMigrator(cmd, func(m *db.Migrator) error {
+					status, err := m.Status()
+					if err != nil {
+						return err
+					}
+					fmt.Fprintln(cmd.OutOrStdout(), status)
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "force VERSION",
+			Short: "Set the schema version without migrating and clear the dirty flag",
+			Long: "Sets the schema version without running any migration and clears the dirty flag. " +
+				"Use it after repairing a failed migration by hand; -1 means no migration applied.",
+			Args: cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := strconv.Atoi(args[0])
+				if err != nil || version < -1 {
+					return fmt.Errorf("invalid version %q", args[0])
+				}
+				return c.migrateWith(cmd, func(m *db.Migrator) error {
+					return m.Force(cmd.Context(), version)
+				})
+			},
+		},
+	)
+	return cmd
+}
+
+func (c *cli) withMigrator(cmd *cobra.Command, fn func(*db.Migrator) error) error {
+	if c.cfg.Storage == config.StorageMemory {
+		return errMemoryStorage
+	}
+	dbConn, err := db.Connect(cmd.Context(), c.cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+
+	m, err := db.NewMigrator(cmd.Context(), dbConn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return fn(m)
+}
+
+// migrateWith runs a migration that changes the schema and logs the version
+// it ended at.
+func (c *cli) migrateWith(cmd *cobra.Command, fn func(*db.Migrator) error) error {
+	return c.withMigrator(cmd, func(m *db.Migrator) error {
+		if err := fn(m); err != nil {
+			return err
+		}
+		status, err := m.Status()
+		if err != nil {
+			return err
+		}
+		slog.Info("migrations done", "status", status.String())
+		return nil
+	})
+}
+
+// migrateUp applies pending migrations on an open pool, for serve --migrate.
+func migrateUp(ctx context.Context, dbConn *sqlx.DB) error {
+	m, err := db.NewMigrator(ctx, dbConn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(ctx); err != nil {
+		return err
+	}
+	slog.Info("migrations applied")
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"petstore/internal/dto"
+	"strings"
+	"testing"
+)
+
+func TestUnacceptableRequestIsRefusedBeforeHandler(t *testing.T) {
+	a := newTestApp(t)
+	srv := newTestServer(t, a)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/user", strings.NewReader(testUser))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/html")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotAcceptable {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusNotAcceptable)
+	}
+	var problem dto.Problem
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Type != "/problems/not-acceptable" {
+		t.Errorf("got problem type %q, want /problems/not-acceptable", problem.Type)
+	}
+	if _, err := a.userService.FindUserByUsername(context.Background(), "jane"); err == nil {
+		t.Error("user was created although the response was refused")
+	}
+}
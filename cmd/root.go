@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"petstore/internal/config"
+	"petstore/internal/logging"
+
+	"github.com/spf13/cobra"
+)
+
+// errConfigPrinted stops the command after --print-config without failing.
+var errConfigPrinted = errors.New("configuration printed")
+
+// cli is shared by all commands: the configuration flags and, once the
+// command line was parsed, the resolved configuration.
+type cli struct {
+	flags *config.Flags
+	cfg   config.Config
+}
+
+func newRootCmd() *cobra.Command {
+	fs := flag.NewFlagSet("petstore", flag.ContinueOnError)
+	c := &cli{flags: config.NewFlags(fs)}
+
+	root := &cobra.Command{
+		Use:               "petstore",
+		Short:             "Petstore API server and operations commands",
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		PersistentPreRunE: c.loadConfig,
+	}
+	root.PersistentFlags().AddGoFlagSet(fs)
+	root.AddCommand(
+		newServeCmd(c),
+		newMigrateCmd(c),
+		newSeedCmd(c),
+		newUserCmd(c),
+		newTokenCmd(c),
+	)
+	return root
+}
+
+// loadConfig resolves the configuration before any command runs. Logs go to
+// stderr so that stdout carries only the output of the command; serve
+// switches them back to stdout.
+func (c *cli) loadConfig(cmd *cobra.Command, _ []string) error {
+	cfg, err := config.Load(c.flags)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if c.flags.PrintConfig {
+		if err := cfg.WriteRedacted(cmd.OutOrStdout()); err != nil {
+			return fmt.Errorf("failed to print configuration: %w", err)
+		}
+		return errConfigPrinted
+	}
+
+	slog.SetDefault(logging.New(cfg.Log, os.Stderr))
+	config.InitJWT(cfg.JWT)
+	c.cfg = cfg
+	return nil
+}
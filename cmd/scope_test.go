@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"petstore/internal/middleware"
+	"petstore/internal/model"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestOrderRoutesRequireStoreScopeForAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	a := newTestApp(t)
+	srv := newTestServer(t, a)
+
+	do(t, http.MethodPost, srv.URL+"/user", testUser)
+	pet, err := a.petService.CreatePet(ctx, model.Pet{Name: "Rex", Status: "available"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := func(scopes ...string) string {
+		created, err := a.apiKeyService.CreateKey(ctx, "jane", model.CreateAPIKeyRequest{Name: "test", Scopes: scopes})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return created.Key
+	}
+	petOnly, store := key(model.ScopePetRead), key(model.ScopeStoreRead, model.ScopeStoreWrite)
+
+	order := `{"petId":` + strconv.Itoa(pet.ID) + `,"quantity":1,"status":"placed","shipDate":"` +
+		time.Now().Add(24*time.Hour).UTC().Format(time.RFC3339) + `"}`
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		key    string
+		want   int
+	}{
+		{"guest places order", http.MethodPost, "/store/order", order, "", http.StatusCreated},
+		{"key without scope places order", http.MethodPost, "/store/order", order, petOnly, http.StatusForbidden},
+		{"key without scope reads order", http.MethodGet, "/store/order/1", "", petOnly, http.StatusForbidden},
+		{"key without scope deletes order", http.MethodDelete, "/store/order/1", "", petOnly, http.StatusForbidden},
+		{"key with scope places order", http.MethodPost, "/store/order", order, store, http.StatusCreated},
+		{"key with scope reads order", http.MethodGet, "/store/order/1", "", store, http.StatusOK},
+	}
+	for _, tt := range tests {
+		var got int
+		if tt.key == "" {
+			got = do(t, tt.method, srv.URL+tt.path, tt.body)
+		} else {
+			got = doWithHeader(t, tt.method, srv.URL+tt.path, tt.body, middleware.APIKeyHeader, tt.key)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got status %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
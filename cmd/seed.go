@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"petstore/internal/model"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// fixture is the demo data loaded by seed. Orders refer to pets by name and
+// to customers by username.
+type fixture struct {
+	Users  []fixtureUser  `yaml:"users"`
+	Pets   []fixturePet   `yaml:"pets"`
+	Orders []fixtureOrder `yaml:"orders"`
+}
+
+type fixtureUser struct {
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+	Email     string `yaml:"email"`
+	FirstName string `yaml:"first_name"`
+	LastName  string `yaml:"last_name"`
+	Phone     string `yaml:"phone"`
+	Role      string `yaml:"role"`
+}
+
+type fixturePet struct {
+	Name      string   `yaml:"name"`
+	Category  string   `yaml:"category"`
+	Tags      []string `yaml:"tags"`
+	PhotoUrls []string `yaml:"photo_urls"`
+	Status    string   `yaml:"status"`
+}
+
+type fixtureOrder struct {
+	Pet      string    `yaml:"pet"`
+	Customer string    `yaml:"customer"`
+	Quantity int       `yaml:"quantity"`
+	ShipDate time.Time `yaml:"ship_date"`
+	Status   string    `yaml:"status"`
+	Complete bool      `yaml:"complete"`
+}
+
+func newSeedCmd(c *cli) *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Load demo pets, users and orders from a fixture file",
+		Long: "Loads demo data into a migrated database in one transaction. Seeding writes nothing when " +
+			"one of the fixture users already exists or any record is rejected, so it is safe to run on " +
+			"every start of a demo setup.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("error reading fixture file: %w", err)
+			}
+			var fx fixture
+			if err := yaml.Unmarshal(data, &fx); err != nil {
+				return fmt.Errorf("error parsing fixture file %s: %w", file, err)
+			}
+			if err := fx.validate(); err != nil {
+				return fmt.Errorf("fixture file %s: %w", file, err)
+			}
+
+			return c.withApp(cmd.Context(), func(a *app) error {
+				return seed(cmd.Context(), a, fx)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&file, "file", "fixtures/demo.yaml", "YAML fixture file")
+	return cmd
+}
+
+// validate checks the references between fixtures, so that a typo is found
+// before the database is touched.
+func (fx fixture) validate() error {
+	pets := make(map[string]bool, len(fx.Pets))
+	for _, fp := range fx.Pets {
+		if pets[fp.Name] {
+			return fmt.Errorf("duplicate pet %q", fp.Name)
+		}
+		pets[fp.Name] = true
+	}
+	users := make(map[string]bool, len(fx.Users))
+	for _, fu := range fx.Users {
+		users[fu.Username] = true
+	}
+
+	for i, fo := range fx.Orders {
+		if !pets[fo.Pet] {
+			return fmt.Errorf("order %d: unknown pet %q", i+1, fo.Pet)
+		}
+		if fo.Customer != "" && !users[fo.Customer] {
+			return fmt.Errorf("order %d: unknown customer %q", i+1, fo.Customer)
+		}
+	}
+	return nil
+}
+
+// seed loads fx in one transaction, so that a failure leaves the database as
+// it was and the next run starts over.
+func seed(ctx context.Context, a *app, fx fixture) error {
+	// Passwords are checked and hashed up front: hashing is slow and must
+	// not hold the transaction open.
+	users := make([]model.User, 0, len(fx.Users))
+	for _, fu := range fx.Users {
+		if err := a.passwords.Validate(fu.Password, fu.Username); err != nil {
+			return fmt.Errorf("user %s: %w", fu.Username, err)
+		}
+		hash, err := a.passwords.Hash(fu.Password)
+		if err != nil {
+			return fmt.Errorf("user %s: failed to hash password: %w", fu.Username, err)
+		}
+		role := fu.Role
+		if role == "" {
+			role = model.RoleCustomer
+		}
+		users = append(users, model.User{
+			Username:   fu.Username,
+			Password:   hash,
+			Email:      fu.Email,
+			FirstName:  fu.FirstName,
+			LastName:   fu.LastName,
+			Phone:      fu.Phone,
+			UserStatus: model.UserStatusActive,
+			Role:       role,
+		})
+	}
+
+	seeded := false
+	err := a.tx.WithinTx(ctx, func(ctx context.Context) error {
+		for _, fu := range fx.Users {
+			_, err := a.users.FindByUsername(ctx, fu.Username)
+			if err == nil {
+				seeded = true
+				return nil
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("failed to look up user %s: %w", fu.Username, err)
+			}
+		}
+
+		for _, user := range users {
+			if _, err := a.users.Create(ctx, user); err != nil {
+				return fmt.Errorf("failed to create user %s: %w", user.Username, err)
+			}
+		}
+
+		petIDs := make(map[string]int, len(fx.Pets))
+		for _, fp := range fx.Pets {
+			pet := model.Pet{
+				Name:      fp.Name,
+				Category:  model.Category{Name: fp.Category},
+				PhotoUrls: fp.PhotoUrls,
+				Status:    fp.Status,
+			}
+			for _, tag := range fp.Tags {
+				pet.Tags = append(pet.Tags, model.Tag{Name: tag})
+			}
+
+			created, err := a.petService.CreatePet(ctx, pet)
+			if err != nil {
+				return fmt.Errorf("failed to create pet %s: %w", fp.Name, err)
+			}
+			petIDs[fp.Name] = created.ID
+		}
+
+		for i, fo := range fx.Orders {
+			if _, err := a.orderService.CreateOrder(ctx, model.Order{
+				PetID:    petIDs[fo.Pet],
+				Quantity: fo.Quantity,
+				ShipDate: fo.ShipDate,
+				Status:   fo.Status,
+				Complete: fo.Complete,
+			}, fo.Customer); err != nil {
+				return fmt.Errorf("order %d: %w", i+1, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if seeded {
+		slog.Info("database already seeded, nothing to do")
+		return nil
+	}
+	slog.Info("database seeded", "users", len(fx.Users), "pets", len(fx.Pets), "orders", len(fx.Orders))
+	return nil
+}
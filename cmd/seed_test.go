@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"petstore/internal/apperror"
+	"testing"
+)
+
+func TestSeedWritesNothingWhenARecordIsRejected(t *testing.T) {
+	ctx := context.Background()
+	a := newTestApp(t)
+
+	// The second user differs only in case, which validate lets through but
+	// the repository rejects after the first user was written.
+	fx := fixture{
+		Users: []fixtureUser{
+			{Username: "jane", Password: "correct-horse-battery"},
+			{Username: "Jane", Password: "correct-horse-battery"},
+		},
+		Pets: []fixturePet{{Name: "Rex", Status: "available"}},
+	}
+	if err := fx.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := seed(ctx, a, fx); apperror.KindOf(err) != apperror.KindConflict {
+		t.Fatalf("got %v, want a conflict", err)
+	}
+	if _, err := a.users.FindByUsername(ctx, "jane"); apperror.KindOf(err) != apperror.KindNotFound {
+		t.Errorf("user jane after failed seed: got %v, want a not found error", err)
+	}
+
+	fx.Users = fx.Users[:1]
+	fx.Orders = []fixtureOrder{{Pet: "Rex", Customer: "jane", Quantity: 1}}
+	if err := seed(ctx, a, fx); err != nil {
+		t.Fatalf("seeding after the failure: %v", err)
+	}
+	// A second run finds jane and writes nothing.
+	if err := seed(ctx, a, fx); err != nil {
+		t.Fatalf("seeding again: %v", err)
+	}
+	pets, err := a.petService.FindPetByStatus(ctx, []string{"available"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pets) != 1 {
+		t.Errorf("got %d pets, want 1", len(pets))
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"petstore/infrastructure"
+	"petstore/internal/config"
+	"petstore/internal/controller"
+	"petstore/internal/db"
+	"petstore/internal/health"
+	"petstore/internal/logging"
+	"petstore/internal/middleware"
+	"petstore/internal/oidc"
+	"petstore/internal/oidc/oidctest"
+	"petstore/internal/tracing"
+	"time"
+
+	httpSwagger "github.com/swaggo/http-swagger"
+
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/cobra"
+)
+
+func newServeCmd(c *cli) *cobra.Command {
+	var migrate bool
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the HTTP server",
+		Long: "Runs the HTTP server until SIGINT or SIGTERM. Migrations are not applied unless --migrate " +
+			"is given; run \"petstore migrate up\" as a deploy step instead.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := c.cfg.JWT.RequireSecret(); err != nil {
+				return fmt.Errorf("invalid configuration: %w", err)
+			}
+			slog.SetDefault(logging.New(c.cfg.Log, os.Stdout))
+			return serve(cmd.Context(), c.cfg, migrate)
+		},
+	}
+	cmd.Flags().BoolVar(&migrate, "migrate", false, "apply pending migrations before starting")
+	return cmd
+}
+
+func serve(ctx context.Context, cfg config.Config, migrate bool) error {
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to init tracing: %w", err)
+	}
+
+	checker := health.NewChecker(2 * time.Second)
+	var (
+		dbConn *sqlx.DB
+		repos  repositories
+	)
+	if cfg.Storage == config.StorageMemory {
+		if migrate {
+			return errors.New("--migrate needs postgres storage")
+		}
+		slog.Warn("using in-memory storage, all data is lost on exit")
+		repos = memoryRepositories()
+	} else {
+		dbConn, err = db.Connect(ctx, cfg.Database)
+		if err != nil {
+			return err
+		}
+		defer dbConn.Close()
+
+		if migrate {
+			if err := migrateUp(ctx, dbConn); err != nil {
+				return err
+			}
+		}
+
+		checker.Add("database", func(ctx context.Context) (string, error) {
+			return "", dbConn.PingContext(ctx)
+		})
+		migrationCheck, err := db.NewMigrationCheck(dbConn)
+		if err != nil {
+			return fmt.Errorf("failed to init migration check: %w", err)
+		}
+		checker.Add("migrations", migrationCheck.Check)
+		repos = postgresRepositories(dbConn, cfg.Database.QueryTimeout)
+	}
+
+	a, err := newApp(cfg, repos)
+	if err != nil {
+		return err
+	}
+	if dbConn != nil {
+		if err := a.metrics.RegisterDB(dbConn.DB, "postgres"); err != nil {
+			return fmt.Errorf("failed to register db metrics: %w", err)
+		}
+	}
+
+	var provider *oidc.Provider
+	if cfg.OIDC.Enabled() {
+		var closeProvider func()
+		provider, closeProvider, err = initOIDC(cfg.OIDC)
+		if err != nil {
+			return fmt.Errorf("failed to init oidc provider: %w", err)
+		}
+		defer closeProvider()
+	}
+
+	server := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      newRouter(a, checker, provider),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return fmt.Errorf("error creating listener: %w", err)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server is starting", "addr", server.Addr)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
+		}
+	}()
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+	}
+	slog.Info("shutting down server", "drain_delay", cfg.Server.DrainDelay.String())
+	checker.ShutDown()
+	time.Sleep(cfg.Server.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("server forced to shutdown: %w", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("failed to flush traces", "err", err)
+	}
+
+	slog.Info("server stopped gracefully")
+	return nil
+}
+
+// newRouter serves the API of the app. OIDC login is only routed if provider
+// is not nil.
+func newRouter(a *app, checker *health.Checker, provider *oidc.Provider) http.Handler {
+	responder := infrastructure.NewNegotiatingResponder()
+
+	petController := &controller.PetController{
+		Service:   a.petService,
+		Responder: responder,
+	}
+
+	orderController := &controller.OrderController{
+		Service:   a.orderService,
+		Responder: responder,
+	}
+
+	userController := &controller.UserController{
+		Service:      a.userService,
+		Responder:    responder,
+		MaxBatchSize: a.cfg.Server.MaxBatchSize,
+	}
+
+	adminController := &controller.AdminController{
+		Service:   a.userService,
+		Responder: responder,
+	}
+
+	apiKeyController := &controller.APIKeyController{
+		Service:   a.apiKeyService,
+		Responder: responder,
+	}
+
+	privacyController := &controller.PrivacyController{
+		Service:   a.privacyService,
+		Responder: responder,
+	}
+
+	auth := middleware.JWTAuthMiddleware(a.apiKeyService, a.userService)
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID, middleware.Tracing, middleware.AccessLog, middleware.Metrics(a.metrics))
+
+	// The privacy routes check the Accept header themselves, as the data
+	// export may be a ZIP archive.
+	controller.RegisterPrivacyRoutes(r, privacyController, auth)
+
+	r.Group(func(r chi.Router) {
+		r.Use(infrastructure.RequireAcceptable)
+
+		controller.RegisterHealthRoutes(r, &controller.HealthController{Checker: checker, Responder: responder})
+		controller.RegisterUserRoutes(r, userController, auth)
+		controller.RegisterOrderRoutes(r, orderController, middleware.OptionalAuth(a.apiKeyService, a.userService))
+		controller.RegisterAdminRoutes(r, adminController, auth)
+		if provider != nil {
+			controller.RegisterOIDCRoutes(r, &controller.OIDCController{
+				Provider:  provider,
+				Service:   a.userService,
+				Responder: responder,
+			})
+		}
+
+		r.Group(func(protected chi.Router) {
+			protected.Use(auth)
+			controller.RegisterAPIKeyRoutes(protected, apiKeyController)
+			protected.Group(func(pets chi.Router) {
+				pets.Use(middleware.RequireScope("pet"))
+				controller.RegisterPetRoutes(pets, petController)
+			})
+			protected.With(middleware.RequireScope("store")).
+				Get("/store/inventory", controller.GetInventory(orderController))
+		})
+	})
+	r.Get("/swagger/*", httpSwagger.WrapHandler)
+	r.Handle("/metrics", a.metrics.Handler())
+	return r
+}
+
+func initOIDC(cfg config.OIDCConfig) (*oidc.Provider, func(), error) {
+	closeProvider := func() {}
+
+	if cfg.Mock {
+		mock, err := oidctest.NewProvider("petstore", "petstore-secret", oidctest.User{
+			Subject:           "mock-user-1",
+			Email:             "jane.staff@example.com",
+			EmailVerified:     true,
+			PreferredUsername: "jane.staff",
+			GivenName:         "Jane",
+			FamilyName:        "Staff",
+			Groups:            []string{"petstore-staff"},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		slog.Info("mock oidc provider running", "issuer", mock.Issuer())
+
+		cfg.IssuerURL = mock.Issuer()
+		cfg.ClientID = mock.ClientID
+		cfg.ClientSecret = mock.ClientSecret
+		if len(cfg.RoleMapping) == 0 {
+			cfg.RoleMapping = map[string]string{"petstore-staff": "staff"}
+		}
+		closeProvider = mock.Close
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	provider, err := oidc.Discover(ctx, oidc.Config{
+		IssuerURL:    cfg.IssuerURL,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       cfg.Scopes,
+		GroupsClaim:  cfg.GroupsClaim,
+		RoleMapping:  cfg.RoleMapping,
+		DefaultRole:  cfg.DefaultRole,
+	}, nil)
+	if err != nil {
+		closeProvider()
+		return nil, nil, err
+	}
+
+	return provider, closeProvider, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"petstore/internal/config"
+	"petstore/internal/health"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newTestApp wires the app on memory storage like "serve --storage=memory".
+func newTestApp(t *testing.T) *app {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.Storage = config.StorageMemory
+	cfg.JWT.Secret = "serve-test-secret-0123456789abcdef"
+	cfg.Mail.Driver = config.MailDriverOutbox
+	cfg.Mail.OutboxDir = t.TempDir()
+	cfg.Password.CheckBreached = false
+	config.InitJWT(cfg.JWT)
+
+	a, err := newApp(cfg, memoryRepositories())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func newTestServer(t *testing.T, a *app) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(newRouter(a, health.NewChecker(time.Second), nil))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// do sends a request with a JSON body, if any, and returns the status code.
+func do(t *testing.T, method, url, body string) int {
+	t.Helper()
+	return doWithHeader(t, method, url, body, "", "")
+}
+
+// doWithHeader is do with one more request header, unless name is empty.
+func doWithHeader(t *testing.T, method, url, body, name, value string) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if name != "" {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+const testUser = `{"username":"jane","email":"jane@example.com","password":"correct-horse-battery"}`
+
+func TestMetricsCountRequestsByRoute(t *testing.T) {
+	a := newTestApp(t)
+	srv := newTestServer(t, a)
+
+	do(t, http.MethodPost, srv.URL+"/user", testUser)
+	do(t, http.MethodGet, srv.URL+"/user/jane", "")
+	do(t, http.MethodGet, srv.URL+"/user/john", "")
+	do(t, http.MethodGet, srv.URL+"/no/such/path", "")
+
+	want := `
+# HELP petstore_http_requests_total HTTP requests by method, route pattern and status code.
+# TYPE petstore_http_requests_total counter
+petstore_http_requests_total{method="GET",route="/user/{username}/",status="200"} 1
+petstore_http_requests_total{method="GET",route="/user/{username}/",status="404"} 1
+petstore_http_requests_total{method="GET",route="unmatched",status="404"} 1
+petstore_http_requests_total{method="POST",route="/user/",status="201"} 1
+`
+	err := testutil.GatherAndCompare(a.metrics.Registry(), strings.NewReader(want), "petstore_http_requests_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMetricsCountFailedLogins(t *testing.T) {
+	a := newTestApp(t)
+	srv := newTestServer(t, a)
+
+	do(t, http.MethodPost, srv.URL+"/user", testUser)
+	do(t, http.MethodPost, srv.URL+"/user/login", `{"username":"jane","password":"wrong-password"}`)
+	do(t, http.MethodPost, srv.URL+"/user/login", `{"username":"john","password":"wrong-password"}`)
+	do(t, http.MethodPost, srv.URL+"/user/login", `{"username":"jane","password":"correct-horse-battery"}`)
+
+	want := `
+# HELP petstore_logins_failed_total Failed login attempts by reason.
+# TYPE petstore_logins_failed_total counter
+petstore_logins_failed_total{reason="invalid_credentials"} 2
+`
+	err := testutil.GatherAndCompare(a.metrics.Registry(), strings.NewReader(want), "petstore_logins_failed_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMetricsTimeRepositoryCalls(t *testing.T) {
+	a := newTestApp(t)
+	srv := newTestServer(t, a)
+
+	do(t, http.MethodPost, srv.URL+"/user", testUser)
+	do(t, http.MethodGet, srv.URL+"/user/jane", "")
+	do(t, http.MethodGet, srv.URL+"/user/jane", "")
+
+	got := histogramCount(t, a.metrics.Registry(), "petstore_repository_query_duration_seconds",
+		map[string]string{"repository": "user", "method": "FindByUsername"})
+	if got != 2 {
+		t.Errorf("got %d FindByUsername observations, want 2", got)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	a := newTestApp(t)
+	srv := newTestServer(t, a)
+
+	do(t, http.MethodGet, srv.URL+"/user/jane", "")
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	want := `petstore_http_requests_total{method="GET",route="/user/{username}/",status="404"} 1`
+	if !strings.Contains(string(body), want) {
+		t.Errorf("scrape lacks %s:\n%s", want, body)
+	}
+}
+
+// histogramCount returns the number of observations of the histogram series
+// with the given labels.
+func histogramCount(t *testing.T, reg *prometheus.Registry, name string, labels map[string]string) uint64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range family.GetMetric() {
+			for _, pair := range m.GetLabel() {
+				if labels[pair.GetName()] != pair.GetValue() {
+					continue metrics
+				}
+			}
+			return m.GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newTokenCmd(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Issue access tokens",
+	}
+	cmd.AddCommand(newTokenIssueCmd(c))
+	return cmd
+}
+
+func newTokenIssueCmd(c *cli) *cobra.Command {
+	var username string
+
+	cmd := &cobra.Command{
+		Use:   "issue",
+		Short: "Print an access token for a user",
+		Long: "Prints an access token for the user, e.g. for scripts and smoke tests. Suspended and " +
+			"erased users get no token.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := c.cfg.JWT.RequireSecret(); err != nil {
+				return fmt.Errorf("invalid configuration: %w", err)
+			}
+			return c.withApp(cmd.Context(), func(a *app) error {
+				token, err := a.userService.IssueAccessToken(cmd.Context(), username)
+				if err != nil {
+					return fmt.Errorf("failed to issue token for %s: %w", username, err)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), token)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&username, "username", "", "user the token is issued for")
+	_ = cmd.MarkFlagRequired("username")
+	return cmd
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"petstore/internal/config"
+	"petstore/internal/tracing"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider that keeps the finished spans in
+// memory, like tracing.Setup does for a real exporter.
+func recordSpans(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewTracerProvider(config.Default().Tracing, exporter)
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return provider, exporter
+}
+
+// spanNamed returns the only span called name.
+func spanNamed(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	var found []tracetest.SpanStub
+	for _, span := range spans {
+		if span.Name == name {
+			found = append(found, span)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("got %d spans named %q, want 1; spans: %v", len(found), name, spanNames(spans))
+	}
+	return found[0]
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+	return names
+}
+
+func TestTracingNestsSpansAndContinuesTrace(t *testing.T) {
+	provider, exporter := recordSpans(t)
+	srv := newTestServer(t, newTestApp(t))
+
+	do(t, http.MethodPost, srv.URL+"/user", testUser)
+	provider.ForceFlush(context.Background())
+	exporter.Reset()
+
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/user/jane", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	spans := exporter.GetSpans()
+
+	server := spanNamed(t, spans, "GET /user/{username}/")
+	svc := spanNamed(t, spans, "UserService.FindUserByUsername")
+	repo := spanNamed(t, spans, "userRepo.FindByUsername")
+
+	if got := server.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("server span has trace %s, want the incoming %s", got, traceID)
+	}
+	if got := server.Parent.SpanID().String(); got != parentID || !server.Parent.IsRemote() {
+		t.Errorf("server span has parent %s, want the remote %s", got, parentID)
+	}
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("server span kind = %v, want %v", server.SpanKind, trace.SpanKindServer)
+	}
+	if svc.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("service span is not a child of the server span")
+	}
+	if repo.Parent.SpanID() != svc.SpanContext.SpanID() {
+		t.Errorf("repository span is not a child of the service span")
+	}
+	if repo.SpanKind != trace.SpanKindClient {
+		t.Errorf("repository span kind = %v, want %v", repo.SpanKind, trace.SpanKindClient)
+	}
+	for _, span := range []tracetest.SpanStub{svc, repo} {
+		if span.SpanContext.TraceID() != server.SpanContext.TraceID() {
+			t.Errorf("span %q is in trace %s, want %s", span.Name, span.SpanContext.TraceID(), server.SpanContext.TraceID())
+		}
+	}
+}
+
+func TestTracingStartsTraceWithoutTraceparent(t *testing.T) {
+	provider, exporter := recordSpans(t)
+	srv := newTestServer(t, newTestApp(t))
+
+	do(t, http.MethodGet, srv.URL+"/user/jane", "")
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	server := spanNamed(t, exporter.GetSpans(), "GET /user/{username}/")
+	if server.Parent.IsValid() {
+		t.Errorf("server span has parent %s, want a root span", server.Parent.SpanID())
+	}
+	if !server.SpanContext.IsSampled() {
+		t.Error("server span is not sampled")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"petstore/internal/model"
+
+	"github.com/spf13/cobra"
+)
+
+func newUserCmd(c *cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage user accounts",
+	}
+	cmd.AddCommand(newCreateAdminCmd(c))
+	return cmd
+}
+
+func newCreateAdminCmd(c *cli) *cobra.Command {
+	var user model.User
+
+	cmd := &cobra.Command{
+		Use:   "create-admin",
+		Short: "Create an active admin account",
+		Long: "Creates an active admin account without email verification. Without --password a random " +
+			"password is generated and printed.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			generated := user.Password == ""
+			if generated {
+				password, err := randomPassword()
+				if err != nil {
+					return err
+				}
+				user.Password = password
+			}
+
+			return c.withApp(cmd.Context(), func(a *app) error {
+				created, err := a.userService.CreateAdmin(cmd.Context(), user)
+				if err != nil {
+					return fmt.Errorf("failed to create admin: %w", err)
+				}
+
+				fmt.Fprintf(cmd.OutOrStdout(), "created admin %s (id %d)\n", created.Username, created.ID)
+				if generated {
+					fmt.Fprintf(cmd.OutOrStdout(), "password: %s\n", user.Password)
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&user.Username, "username", "", "username of the admin")
+	cmd.Flags().StringVar(&user.Email, "email", "", "email address of the admin")
+	cmd.Flags().StringVar(&user.Password, "password", "", "password, generated when empty")
+	cmd.Flags().StringVar(&user.FirstName, "first-name", "", "first name")
+	cmd.Flags().StringVar(&user.LastName, "last-name", "", "last name")
+	_ = cmd.MarkFlagRequired("username")
+	return cmd
+}
+
+// randomPassword returns 24 random bytes encoded as 32 characters.
+func randomPassword() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
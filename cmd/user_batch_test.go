@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"petstore/internal/model"
+	"strings"
+	"testing"
+)
+
+func TestUserBatchNeedsAdminAndIsCapped(t *testing.T) {
+	ctx := context.Background()
+	a := newTestApp(t)
+	a.cfg.Server.MaxBatchSize = 3
+	srv := newTestServer(t, a)
+
+	bearer := func(username string) string {
+		token, err := a.userService.IssueAccessToken(ctx, username)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + token
+	}
+	if _, err := a.userService.CreateAdmin(ctx, model.User{Username: "root", Password: "correct-horse-battery"}); err != nil {
+		t.Fatal(err)
+	}
+	do(t, http.MethodPost, srv.URL+"/user", testUser)
+	admin, customer := bearer("root"), bearer("jane")
+
+	batch := func(n int) string {
+		users := make([]string, n)
+		for i := range users {
+			users[i] = fmt.Sprintf(`{"username":"batch%d","password":"correct-horse-battery"}`, i)
+		}
+		return "[" + strings.Join(users, ",") + "]"
+	}
+	url := srv.URL + "/user/createWithList"
+
+	if got := do(t, http.MethodPost, url, batch(1)); got != http.StatusUnauthorized {
+		t.Errorf("anonymous batch: got status %d, want %d", got, http.StatusUnauthorized)
+	}
+	if got := doWithHeader(t, http.MethodPost, url, batch(1), "Authorization", customer); got != http.StatusForbidden {
+		t.Errorf("customer batch: got status %d, want %d", got, http.StatusForbidden)
+	}
+	if got := doWithHeader(t, http.MethodPost, url, batch(4), "Authorization", admin); got != http.StatusBadRequest {
+		t.Errorf("batch over the limit: got status %d, want %d", got, http.StatusBadRequest)
+	}
+	if _, err := a.userService.FindUserByUsername(ctx, "batch0"); err == nil {
+		t.Error("a user of the rejected batch was created")
+	}
+	if got := doWithHeader(t, http.MethodPost, url, batch(3), "Authorization", admin); got != http.StatusCreated {
+		t.Errorf("admin batch: got status %d, want %d", got, http.StatusCreated)
+	}
+}
@@ -15,330 +15,349 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/pet": {
-            "put": {
+        "/admin/erasure-requests": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update an existing pet in the store",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Admin only.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "pet"
+                    "privacy"
                 ],
-                "summary": "Update an existing pet",
+                "summary": "List erasure requests",
                 "parameters": [
                     {
-                        "description": "Pet to update",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.Pet"
-                        }
+                        "enum": [
+                            "pending",
+                            "approved",
+                            "rejected"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Pet"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ErasureRequest"
+                            }
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/erasure-requests/{requestId}/approve": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Admin only. Anonymizes the user's personal fields and removes their credentials, tokens and API keys. Cannot be undone.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "pet"
+                    "privacy"
                 ],
-                "summary": "Add a new pet to the store",
+                "summary": "Approve an erasure request",
                 "parameters": [
                     {
-                        "description": "Pet to add",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.Pet"
-                        }
+                        "type": "integer",
+                        "description": "ID of the erasure request",
+                        "name": "requestId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Pet"
+                            "$ref": "#/definitions/model.ErasureRequest"
+                        }
+                    },
+                    "404": {
+                        "description": "request not found or already decided",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             }
         },
-        "/pet/findByStatus": {
-            "get": {
+        "/admin/erasure-requests/{requestId}/reject": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Multiple status values can be provided with comma separated strings",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Admin only.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "pet"
+                    "privacy"
                 ],
-                "summary": "Finds Pets by status",
+                "summary": "Reject an erasure request",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "enum": [
-                                "available",
-                                "pending",
-                                "sold"
-                            ],
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Status values that need to be considered for filter",
-                        "name": "status",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "ID of the erasure request",
+                        "name": "requestId",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "successful operation",
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Pet"
-                            }
+                            "$ref": "#/definitions/model.ErasureRequest"
+                        }
+                    },
+                    "404": {
+                        "description": "request not found or already decided",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             }
         },
-        "/pet/findByTags": {
+        "/admin/users": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Multiple tags can be provided with comma separated strings. Use tag1, tag2, tag3 for testing.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Admin only. Searches username, email and name case-insensitively.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "pet"
+                    "admin"
                 ],
-                "summary": "Finds Pets by tags",
-                "deprecated": true,
+                "summary": "List users",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Tags to filter by",
-                        "name": "tags",
-                        "in": "query",
-                        "required": true
+                        "type": "string",
+                        "description": "Search text",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by user status (0 unverified, 1 active, 2 erased, 3 suspended)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of users to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "successful operation",
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Pet"
-                            }
+                            "$ref": "#/definitions/dto.UserPage"
                         }
                     }
                 }
             }
         },
-        "/pet/{petId}": {
+        "/admin/users/{username}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns a single pet",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Admin only. Includes the last login time.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "pet"
+                    "admin"
                 ],
-                "summary": "Find pet by ID",
+                "summary": "Get user details",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID of pet to return",
-                        "name": "petId",
+                        "type": "string",
+                        "description": "The user to fetch",
+                        "name": "username",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "successful operation",
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.AdminUserResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "user not found",
                         "schema": {
-                            "$ref": "#/definitions/model.Pet"
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/users/{username}/force-password-reset": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Updates name and status of pet",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+                "description": "Admin only. Invalidates the current password and emails the user a reset link.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "pet"
+                    "admin"
                 ],
-                "summary": "Updates a pet in the store with form data",
+                "summary": "Force a password reset",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID of pet that needs to be updated",
-                        "name": "petId",
+                        "type": "string",
+                        "description": "The user whose password is reset",
+                        "name": "username",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Updated name of the pet",
-                        "name": "name",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Updated status of the pet",
-                        "name": "status",
-                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "successful operation",
                         "schema": {
-                            "$ref": "#/definitions/model.Pet"
+                            "$ref": "#/definitions/model.ApiResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "user not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "user has no email address",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/users/{username}/reactivate": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Deletes a pet by ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Admin only.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "pet"
+                    "admin"
                 ],
-                "summary": "Deletes a pet",
+                "summary": "Reactivate a suspended user",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Pet id to delete",
-                        "name": "petId",
+                        "type": "string",
+                        "description": "The user to reactivate",
+                        "name": "username",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "successful operation",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.ApiResponse"
+                            "$ref": "#/definitions/dto.AdminUserResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "user not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "user is not suspended",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             }
         },
-        "/pet/{petId}/uploadImage": {
+        "/admin/users/{username}/suspend": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "consumes": [
-                    "multipart/form-data"
-                ],
+                "description": "Admin only. Blocks password, two-factor, SSO and API key logins until the user is reactivated.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "pet"
+                    "admin"
                 ],
-                "summary": "uploads an image",
+                "summary": "Suspend a user",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "ID of pet to update",
-                        "name": "petId",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "string",
-                        "description": "Additional data to pass to server",
-                        "name": "additionalMetadata",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "file",
-                        "description": "File to upload",
-                        "name": "file",
-                        "in": "formData",
+                        "description": "The user to suspend",
+                        "name": "username",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -346,327 +365,446 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.ApiResponse"
+                            "$ref": "#/definitions/dto.AdminUserResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "user not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "user cannot be suspended",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             }
         },
-        "/store/inventory": {
+        "/apikeys": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns a map of status codes to quantities",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists the logged in user's keys, including revoked ones. Secrets are never returned.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "store"
+                    "apikeys"
                 ],
-                "summary": "Returns pet inventories by status",
+                "summary": "List API keys",
                 "responses": {
                     "200": {
-                        "description": "successful operation",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "integer"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.APIKey"
                             }
                         }
                     }
                 }
-            }
-        },
-        "/store/order": {
+            },
             "post": {
-                "description": "Places a new order in the system",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Issues a key for the logged in user, limited to the given scopes. The key is returned only once; send it in the X-API-Key header.",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "store"
+                    "apikeys"
                 ],
-                "summary": "Place an order for a pet",
+                "summary": "Issue an API key",
                 "parameters": [
                     {
-                        "description": "order placed for purchasing the pet",
-                        "name": "order",
+                        "description": "Key name and scopes",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.Order"
+                            "$ref": "#/definitions/model.CreateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.Order"
+                            "$ref": "#/definitions/model.CreatedAPIKey"
                         }
                     }
                 }
             }
         },
-        "/store/order/{orderId}": {
-            "get": {
-                "description": "For valid response try integer IDs with value \u003e= 1 and \u003c= 10. Other values will generate exceptions",
-                "consumes": [
-                    "application/json"
+        "/apikeys/{keyId}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "store"
+                    "apikeys"
                 ],
-                "summary": "Find purchase order by ID",
+                "summary": "Revoke an API key",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID of pet that needs to be fetched",
-                        "name": "orderId",
+                        "description": "ID of the key to revoke",
+                        "name": "keyId",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/model.Order"
-                        }
+                    "204": {
+                        "description": "key revoked"
                     }
                 }
-            },
-            "delete": {
-                "description": "For valid response try integer IDs with positive integer value. Negative or non-integer values will generate API errors",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/auth/oidc/callback": {
+            "get": {
+                "description": "Completes the authorization code flow, provisions the user on first login and returns an access token.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "store"
+                    "auth"
                 ],
-                "summary": "Delete purchase order by ID",
+                "summary": "OpenID Connect callback",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID of the order that needs to be deleted",
-                        "name": "orderId",
-                        "in": "path",
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State from /auth/oidc/login",
+                        "name": "state",
+                        "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "successful operation",
+                        "description": "token",
                         "schema": {
-                            "$ref": "#/definitions/model.ApiResponse"
+                            "$ref": "#/definitions/model.LoginResult"
                         }
                     }
                 }
             }
         },
-        "/user": {
-            "post": {
-                "description": "This can only be done by the logged in user.",
-                "consumes": [
-                    "application/json"
+        "/auth/oidc/login": {
+            "get": {
+                "description": "Redirects to the OpenID Connect provider. After sign-in the provider redirects back to /auth/oidc/callback.",
+                "tags": [
+                    "auth"
                 ],
+                "summary": "Sign in with the company identity provider",
+                "responses": {
+                    "302": {
+                        "description": "redirect to the identity provider"
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Answers as long as the process is able to serve requests. Dependencies are not checked.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "user"
-                ],
-                "summary": "Create user",
-                "parameters": [
-                    {
-                        "description": "Created user object",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.User"
-                        }
-                    }
+                    "health"
                 ],
+                "summary": "Liveness probe",
                 "responses": {
                     "200": {
-                        "description": "successful operation",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.ApiResponse"
+                            "$ref": "#/definitions/dto.HealthResponse"
                         }
                     }
                 }
             }
         },
-        "/user/createWithArray": {
-            "post": {
-                "description": "Creates list of users with given input array",
+        "/pet": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Update an existing pet in the store",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "user"
+                    "pet"
                 ],
-                "summary": "Creates list of users with given input array",
+                "summary": "Update an existing pet",
                 "parameters": [
                     {
-                        "description": "List of user object",
+                        "description": "Pet to update",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.User"
-                            }
+                            "$ref": "#/definitions/dto.PetRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "successful operation",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.ApiResponse"
+                            "$ref": "#/definitions/dto.PetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid pet, with the offending fields",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "pet not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/user/createWithList": {
+            },
             "post": {
-                "description": "Creates list of users with given input array",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "user"
+                    "pet"
                 ],
-                "summary": "Creates list of users with given input array",
+                "summary": "Add a new pet to the store",
                 "parameters": [
                     {
-                        "description": "List of user object",
+                        "description": "Pet to add",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.User"
-                            }
+                            "$ref": "#/definitions/dto.PetRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "successful operation",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.ApiResponse"
+                            "$ref": "#/definitions/dto.PetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid pet, with the offending fields",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             }
         },
-        "/user/login": {
+        "/pet/findByStatus": {
             "get": {
-                "description": "Logs user into the system",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Multiple status values can be provided with comma separated strings",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "user"
+                    "pet"
                 ],
-                "summary": "Logs user into the system",
+                "summary": "Finds Pets by status",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "The user name for login",
-                        "name": "username",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "The password for login in clear text",
-                        "name": "password",
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "available",
+                                "pending",
+                                "sold"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Status values that need to be considered for filter",
+                        "name": "status",
                         "in": "query",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "token",
+                        "description": "successful operation",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.PetResponse"
+                            }
                         }
                     }
                 }
             }
         },
-        "/user/logout": {
+        "/pet/findByTags": {
             "get": {
-                "description": "Logs out current logged in user session",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Multiple tags can be provided with comma separated strings. Use tag1, tag2, tag3 for testing.",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "user"
+                    "pet"
+                ],
+                "summary": "Finds Pets by tags",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Tags to filter by",
+                        "name": "tags",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
-                "summary": "Logs out current logged in user session",
                 "responses": {
                     "200": {
-                        "description": "ok",
+                        "description": "successful operation",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.PetResponse"
+                            }
                         }
                     }
                 }
             }
         },
-        "/user/{username}": {
+        "/pet/{petId}": {
             "get": {
-                "description": "The name that needs to be fetched. Use user1 for testing.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Returns a single pet",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "user"
+                    "pet"
                 ],
-                "summary": "Get user by user name",
+                "summary": "Find pet by ID",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "The name that needs to be fetched",
-                        "name": "username",
+                        "type": "integer",
+                        "description": "ID of pet to return",
+                        "name": "petId",
                         "in": "path",
                         "required": true
                     }
@@ -675,67 +813,356 @@ const docTemplate = `{
                     "200": {
                         "description": "successful operation",
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/dto.PetResponse"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "This can only be done by the logged in user.",
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Updates name and status of pet",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "user"
+                    "pet"
                 ],
-                "summary": "Updated user",
+                "summary": "Updates a pet in the store with form data",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "name that need to be updated",
-                        "name": "username",
+                        "type": "integer",
+                        "description": "ID of pet that needs to be updated",
+                        "name": "petId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Updated user object",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.User"
-                        }
+                        "type": "string",
+                        "description": "Updated name of the pet",
+                        "name": "name",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Updated status of the pet",
+                        "name": "status",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "successful operation",
                         "schema": {
-                            "$ref": "#/definitions/model.User"
+                            "$ref": "#/definitions/dto.PetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid name or status",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "This can only be done by the logged in user.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Deletes a pet by ID",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
                 ],
                 "tags": [
-                    "user"
+                    "pet"
                 ],
-                "summary": "Delete user",
+                "summary": "Deletes a pet",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "The name that needs to be deleted",
-                        "name": "username",
+                        "type": "integer",
+                        "description": "Pet id to delete",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "successful operation",
+                        "schema": {
+                            "$ref": "#/definitions/model.ApiResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "pet not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/pet/{petId}/uploadImage": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "pet"
+                ],
+                "summary": "uploads an image",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet to update",
+                        "name": "petId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Additional data to pass to server",
+                        "name": "additionalMetadata",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ApiResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Checks the database connection and that the schema is at the latest migration. Reports not ready while the server shuts down.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "a component is down or the server is shutting down",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/store/inventory": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "XAPIKey": []
+                    }
+                ],
+                "description": "Returns a map of status codes to quantities",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Returns pet inventories by status",
+                "responses": {
+                    "200": {
+                        "description": "successful operation",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/store/order": {
+            "post": {
+                "description": "Places a new order in the system. Orders placed with credentials are linked to the customer's account.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Place an order for a pet",
+                "parameters": [
+                    {
+                        "description": "order placed for purchasing the pet",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid order, with the offending fields",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "api key lacks the store:write scope",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/store/order/{orderId}": {
+            "get": {
+                "description": "For valid response try integer IDs with value \u003e= 1 and \u003c= 10. Other values will generate exceptions",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Find purchase order by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of pet that needs to be fetched",
+                        "name": "orderId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrderResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "api key lacks the store:read scope",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "order not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "For valid response try integer IDs with positive integer value. Negative or non-integer values will generate API errors",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "store"
+                ],
+                "summary": "Delete purchase order by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the order that needs to be deleted",
+                        "name": "orderId",
                         "in": "path",
                         "required": true
                     }
@@ -746,153 +1173,1658 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/model.ApiResponse"
                         }
+                    },
+                    "403": {
+                        "description": "api key lacks the store:write scope",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "order not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "completed orders cannot be deleted",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "post": {
+                "description": "This can only be done by the logged in user.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Create user",
+                "parameters": [
+                    {
+                        "description": "Created user object",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "successful operation",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid user or password rejected by the password policy",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "username or email already taken",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Verifies the first code from the authenticator, enables two-factor authentication and returns one-time recovery codes.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Confirm two-factor enrollment",
+                "parameters": [
+                    {
+                        "description": "Code from the authenticator app",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TOTPConfirmRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "recovery codes, shown only once",
+                        "schema": {
+                            "$ref": "#/definitions/model.RecoveryCodes"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Generates a TOTP secret for the logged in user. Two-factor authentication is enabled only after /user/2fa/confirm.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Start two-factor enrollment",
+                "responses": {
+                    "200": {
+                        "description": "secret and otpauth URI",
+                        "schema": {
+                            "$ref": "#/definitions/model.TOTPEnrollment"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/createWithArray": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admin only. Validates and stores the users in one transaction. In atomic mode (default) nothing is stored if any user is invalid; in partial mode valid users are stored and the rest reported. Batches larger than the configured maximum are rejected.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Creates list of users with given input array",
+                "parameters": [
+                    {
+                        "enum": [
+                            "atomic",
+                            "partial"
+                        ],
+                        "type": "string",
+                        "description": "atomic (default) or partial",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "description": "List of user object",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.UserRequest"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "all users created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserBatchReport"
+                        }
+                    },
+                    "207": {
+                        "description": "some users failed (partial mode)",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserBatchReport"
+                        }
+                    },
+                    "400": {
+                        "description": "batch rejected (atomic mode), or a problem if the batch is too large",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserBatchReport"
+                        }
+                    },
+                    "401": {
+                        "description": "authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/createWithList": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Admin only. Validates and stores the users in one transaction. In atomic mode (default) nothing is stored if any user is invalid; in partial mode valid users are stored and the rest reported. Batches larger than the configured maximum are rejected.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Creates list of users with given input array",
+                "parameters": [
+                    {
+                        "enum": [
+                            "atomic",
+                            "partial"
+                        ],
+                        "type": "string",
+                        "description": "atomic (default) or partial",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "description": "List of user object",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.UserRequest"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "all users created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserBatchReport"
+                        }
+                    },
+                    "207": {
+                        "description": "some users failed (partial mode)",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserBatchReport"
+                        }
+                    },
+                    "400": {
+                        "description": "batch rejected (atomic mode), or a problem if the batch is too large",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserBatchReport"
+                        }
+                    },
+                    "401": {
+                        "description": "authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "caller is not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/login": {
+            "get": {
+                "description": "Logs user into the system. Deprecated: the password ends up in access logs, use POST /user/login instead.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Logs user into the system",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The user name for login",
+                        "name": "username",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "The password for login in clear text",
+                        "name": "password",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "token, or a challenge token if two-factor authentication is enabled",
+                        "schema": {
+                            "$ref": "#/definitions/model.LoginResult"
+                        }
+                    },
+                    "403": {
+                        "description": "account is suspended",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Accepts credentials as a JSON or form-encoded body. Repeated failures are throttled per username and per client IP.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Logs user into the system",
+                "parameters": [
+                    {
+                        "description": "Login credentials",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "token, or a challenge token if two-factor authentication is enabled",
+                        "schema": {
+                            "$ref": "#/definitions/model.LoginResult"
+                        }
+                    },
+                    "403": {
+                        "description": "account is suspended",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/login/2fa": {
+            "post": {
+                "description": "Exchanges the challenge token returned by /user/login and a TOTP or recovery code for an access token.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Complete a two-factor login",
+                "parameters": [
+                    {
+                        "description": "Challenge token and code",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TwoFactorLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "token",
+                        "schema": {
+                            "$ref": "#/definitions/model.LoginResult"
+                        }
+                    },
+                    "403": {
+                        "description": "account is suspended",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/logout": {
+            "get": {
+                "description": "Logs out current logged in user session",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Logs out current logged in user session",
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password/forgot": {
+            "post": {
+                "description": "Sends a single-use reset token to the email address if it belongs to an account. The response is the same whether or not it does.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Request a password reset",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "request accepted",
+                        "schema": {
+                            "$ref": "#/definitions/model.ApiResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password/reset": {
+            "post": {
+                "description": "Sets a new password using a token from the reset email. Tokens are single-use and expire after an hour.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Reset token and new password",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "successful operation",
+                        "schema": {
+                            "$ref": "#/definitions/model.ApiResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid token or password rejected by the password policy",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/verify": {
+            "get": {
+                "description": "Confirms the email address using the token from the verification email and activates the account.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Verify email address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Verification token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "successful operation",
+                        "schema": {
+                            "$ref": "#/definitions/model.ApiResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid or expired token",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "account is not awaiting email verification",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{username}": {
+            "get": {
+                "description": "The name that needs to be fetched. Use user1 for testing.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get user by user name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The name that needs to be fetched",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "successful operation",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Only the user themselves or an admin may update the user.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Updated user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "name that need to be updated",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated user object. An empty password keeps the current one.",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "successful operation",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "password rejected by the password policy",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "not allowed to update this user",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "email already taken",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Only the user themselves or an admin may delete the user.",
+                "consumes": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The name that needs to be deleted",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "user deleted"
+                    },
+                    "401": {
+                        "description": "not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "not allowed to delete this user",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "user not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{username}/erasure": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Files a request to anonymize the user's personal data. An admin has to approve it; orders are kept for accounting but no longer identify the user.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "privacy"
+                ],
+                "summary": "Request account erasure",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The user to erase",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErasureRequest"
+                        }
+                    },
+                    "403": {
+                        "description": "not allowed to erase this user",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "a request is already pending",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{username}/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns everything stored about the user: profile, orders, API keys and erasure requests. Only the user themselves or an admin may export. The default format is a ZIP archive with one JSON file per section.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/yaml",
+                    "application/zip"
+                ],
+                "tags": [
+                    "privacy"
+                ],
+                "summary": "Export user data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The user whose data is exported",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "zip",
+                            "json"
+                        ],
+                        "type": "string",
+                        "description": "zip (default) or json",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserDataExport"
+                        }
+                    },
+                    "403": {
+                        "description": "not allowed to export this user",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "user not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apperror.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AdminUserResponse": {
+            "type": "object",
+            "properties": {
+                "authProvider": {
+                    "type": "string",
+                    "example": "https://idp.example.com"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "johndoe@example.com"
+                },
+                "firstName": {
+                    "type": "string",
+                    "example": "John"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "lastLoginAt": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string",
+                    "example": "Doe"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+123456789"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "customer"
+                },
+                "totpEnabled": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "userStatus": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "username": {
+                    "type": "string",
+                    "example": "johndoe"
+                }
+            }
+        },
+        "dto.Category": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 2
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Dog"
+                }
+            }
+        },
+        "dto.ComponentHealth": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "version 10"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "database"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "up"
+                }
+            }
+        },
+        "dto.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ComponentHealth"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "up"
+                }
+            }
+        },
+        "dto.OrderRequest": {
+            "type": "object",
+            "required": [
+                "petId",
+                "quantity",
+                "shipDate"
+            ],
+            "properties": {
+                "complete": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "petId": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 3
+                },
+                "quantity": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 1,
+                    "example": 2
+                },
+                "shipDate": {
+                    "type": "string",
+                    "example": "2030-03-29T15:04:05Z"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "placed",
+                        "approved",
+                        "delivered"
+                    ],
+                    "example": "placed"
+                }
+            }
+        },
+        "dto.OrderResponse": {
+            "type": "object",
+            "properties": {
+                "complete": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "petId": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "shipDate": {
+                    "type": "string",
+                    "example": "2025-03-29T15:04:05Z"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "placed"
+                }
+            }
+        },
+        "dto.PetRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "status"
+            ],
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/dto.Category"
+                },
+                "id": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Rex"
+                },
+                "photoUrls": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "https://example.com/photo.jpg"
+                    ]
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "available",
+                        "pending",
+                        "sold"
+                    ],
+                    "example": "available"
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "$ref": "#/definitions/dto.Tag"
+                    }
+                }
+            }
+        },
+        "dto.PetResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/dto.Category"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Rex"
+                },
+                "photoUrls": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "https://example.com/photo.jpg"
+                    ]
+                },
+                "status": {
+                    "type": "string",
+                    "example": "available"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Tag"
+                    }
+                }
+            }
+        },
+        "dto.Problem": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "pet with ID 7 not found"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apperror.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/pet/7"
+                },
+                "requestId": {
+                    "type": "string",
+                    "example": "3f1c9a6e0b7d4e21"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "/problems/not-found"
+                }
+            }
+        },
+        "dto.Tag": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "cute"
+                }
+            }
+        },
+        "dto.UserBatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "username is required"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "failed",
+                        "skipped"
+                    ],
+                    "example": "created"
+                },
+                "user": {
+                    "$ref": "#/definitions/dto.UserResponse"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "johndoe"
+                }
+            }
+        },
+        "dto.UserBatchReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.UserBatchItemResult"
+                    }
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "atomic"
+                }
+            }
+        },
+        "dto.UserDataExport": {
+            "type": "object",
+            "properties": {
+                "apiKeys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.APIKey"
+                    }
+                },
+                "erasureRequests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ErasureRequest"
+                    }
+                },
+                "exportedAt": {
+                    "type": "string"
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderResponse"
                     }
+                },
+                "profile": {
+                    "$ref": "#/definitions/dto.UserResponse"
                 }
             }
-        }
-    },
-    "definitions": {
-        "model.ApiResponse": {
+        },
+        "dto.UserPage": {
             "type": "object",
             "properties": {
-                "code": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AdminUserResponse"
+                    }
+                },
+                "limit": {
                     "type": "integer",
-                    "example": 200
+                    "example": 20
                 },
-                "message": {
-                    "type": "string",
-                    "example": "operation completed successfully"
+                "offset": {
+                    "type": "integer",
+                    "example": 0
                 },
-                "type": {
-                    "type": "string",
-                    "example": "success"
+                "total": {
+                    "type": "integer",
+                    "example": 42
                 }
             }
         },
-        "model.Category": {
+        "dto.UserRequest": {
             "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
             "properties": {
-                "id": {
-                    "type": "integer",
-                    "example": 2
+                "email": {
+                    "type": "string",
+                    "maxLength": 254,
+                    "example": "johndoe@example.com"
                 },
-                "name": {
+                "firstName": {
                     "type": "string",
-                    "example": "Dog"
+                    "maxLength": 100,
+                    "example": "John"
+                },
+                "lastName": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Doe"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "secret123"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+123456789"
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "johndoe"
                 }
             }
         },
-        "model.Order": {
+        "dto.UserResponse": {
             "type": "object",
             "properties": {
-                "complete": {
-                    "type": "boolean",
-                    "example": false
+                "email": {
+                    "type": "string",
+                    "example": "johndoe@example.com"
+                },
+                "firstName": {
+                    "type": "string",
+                    "example": "John"
                 },
                 "id": {
                     "type": "integer",
-                    "example": 10
+                    "example": 1
                 },
-                "petId": {
-                    "type": "integer",
-                    "example": 3
+                "lastName": {
+                    "type": "string",
+                    "example": "Doe"
                 },
-                "quantity": {
+                "phone": {
+                    "type": "string",
+                    "example": "+123456789"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "customer"
+                },
+                "totpEnabled": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "userStatus": {
                     "type": "integer",
-                    "example": 2
+                    "example": 1
                 },
-                "shipDate": {
+                "username": {
                     "type": "string",
-                    "example": "2025-03-29T15:04:05Z"
+                    "example": "johndoe"
+                }
+            }
+        },
+        "dto.UserUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 254,
+                    "example": "johndoe@example.com"
                 },
-                "status": {
+                "firstName": {
                     "type": "string",
-                    "example": "placed"
+                    "maxLength": 100,
+                    "example": "John"
+                },
+                "lastName": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Doe"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "secret123"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+123456789"
                 }
             }
         },
-        "model.Pet": {
+        "model.APIKey": {
             "type": "object",
             "properties": {
-                "category": {
-                    "$ref": "#/definitions/model.Category"
+                "createdAt": {
+                    "type": "string"
                 },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
+                "lastUsedAt": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string",
-                    "example": "Rex"
+                    "example": "warehouse sync"
                 },
-                "photoUrls": {
+                "prefix": {
+                    "type": "string",
+                    "example": "3f9a1c0b7e2d4a65"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     },
                     "example": [
-                        "[\"https://example.com/photo.jpg\"]"
+                        "pet:read",
+                        "store:read"
                     ]
                 },
-                "status": {
+                "username": {
                     "type": "string",
-                    "example": "available"
+                    "example": "warehouse-sync"
+                }
+            }
+        },
+        "model.ApiResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 200
                 },
-                "tags": {
+                "message": {
+                    "type": "string",
+                    "example": "operation completed successfully"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        },
+        "model.CreateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "warehouse sync"
+                },
+                "scopes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/model.Tag"
-                    }
+                        "type": "string"
+                    },
+                    "example": [
+                        "pet:read",
+                        "store:read"
+                    ]
                 }
             }
         },
-        "model.Tag": {
+        "model.CreatedAPIKey": {
             "type": "object",
             "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
+                "key": {
+                    "type": "string",
+                    "example": "psk_3f9a1c0b7e2d4a65_Zm9vYmFy..."
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string",
-                    "example": "cute"
+                    "example": "warehouse sync"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "3f9a1c0b7e2d4a65"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "pet:read",
+                        "store:read"
+                    ]
+                },
+                "username": {
+                    "type": "string",
+                    "example": "warehouse-sync"
                 }
             }
         },
-        "model.User": {
+        "model.ErasureRequest": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "johndoe@example.com"
+                "decidedAt": {
+                    "type": "string"
                 },
-                "firstName": {
-                    "type": "string",
-                    "example": "John"
+                "decidedBy": {
+                    "type": "string"
                 },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
-                "lastName": {
+                "requestedAt": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string",
-                    "example": "Doe"
+                    "example": "pending"
+                },
+                "userId": {
+                    "type": "integer",
+                    "example": 1
                 },
+                "username": {
+                    "type": "string",
+                    "example": "johndoe"
+                }
+            }
+        },
+        "model.ForgotPasswordRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "johndoe@example.com"
+                }
+            }
+        },
+        "model.LoginRequest": {
+            "type": "object",
+            "properties": {
                 "password": {
                     "type": "string",
                     "example": "secret123"
                 },
-                "phone": {
+                "username": {
                     "type": "string",
-                    "example": "+123456789"
+                    "example": "johndoe"
+                }
+            }
+        },
+        "model.LoginResult": {
+            "type": "object",
+            "properties": {
+                "challengeToken": {
+                    "type": "string"
                 },
-                "userStatus": {
-                    "type": "integer",
-                    "example": 1
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOi..."
                 },
-                "username": {
+                "twoFactorRequired": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "model.RecoveryCodes": {
+            "type": "object",
+            "properties": {
+                "recoveryCodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "abcde-fghij"
+                    ]
+                }
+            }
+        },
+        "model.ResetPasswordRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
                     "type": "string",
-                    "example": "johndoe"
+                    "example": "newSecret123"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "q9Xx0c4d..."
+                }
+            }
+        },
+        "model.TOTPConfirmRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "model.TOTPEnrollment": {
+            "type": "object",
+            "properties": {
+                "otpauthUri": {
+                    "type": "string",
+                    "example": "otpauth://totp/Petstore:johndoe?secret=JBSWY3DPEHPK3PXP\u0026issuer=Petstore"
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXP"
+                }
+            }
+        },
+        "model.TwoFactorLoginRequest": {
+            "type": "object",
+            "properties": {
+                "challengeToken": {
+                    "type": "string",
+                    "example": "eyJhbGciOi..."
+                },
+                "code": {
+                    "type": "string",
+                    "example": "123456"
                 }
             }
         }
@@ -902,6 +2834,11 @@ const docTemplate = `{
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
+        },
+        "XAPIKey": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
     }
 }`
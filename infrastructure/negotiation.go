@@ -0,0 +1,307 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"petstore/internal/apperror"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	MediaTypeJSON = "application/json"
+	MediaTypeXML  = "application/xml"
+	MediaTypeYAML = "application/yaml"
+)
+
+// format is a representation the API can read and write.
+type format struct {
+	mediaType   string
+	problemType string
+	aliases     []string
+	marshal     func(v interface{}) ([]byte, error)
+	unmarshal   func(data []byte, v interface{}) error
+}
+
+// formats lists the supported representations, JSON first as the default.
+var formats = []format{
+	{
+		mediaType:   MediaTypeJSON,
+		problemType: "application/problem+json",
+		aliases:     []string{"application/problem+json", "text/json"},
+		marshal:     marshalJSON,
+		unmarshal:   json.Unmarshal,
+	},
+	{
+		mediaType:   MediaTypeXML,
+		problemType: "application/problem+xml",
+		aliases:     []string{"application/problem+xml", "text/xml"},
+		marshal:     marshalXML,
+		unmarshal:   unmarshalXML,
+	},
+	{
+		mediaType:   MediaTypeYAML,
+		problemType: MediaTypeYAML,
+		aliases:     []string{"application/x-yaml", "text/yaml", "text/x-yaml"},
+		marshal:     marshalYAML,
+		unmarshal:   unmarshalYAML,
+	},
+}
+
+var (
+	ErrUnsupportedMediaType = apperror.New(apperror.KindUnsupportedMediaType,
+		"unsupported content type, use one of %s", strings.Join(supportedMediaTypes(), ", "))
+	ErrNotAcceptable = apperror.New(apperror.KindNotAcceptable,
+		"supported media types are %s", strings.Join(supportedMediaTypes(), ", "))
+)
+
+func supportedMediaTypes() []string {
+	types := make([]string, len(formats))
+	for i, f := range formats {
+		types[i] = f.mediaType
+	}
+	return types
+}
+
+func formatFor(mediaType string) (format, bool) {
+	for _, f := range formats {
+		if f.mediaType == mediaType {
+			return f, true
+		}
+		for _, a := range f.aliases {
+			if a == mediaType {
+				return f, true
+			}
+		}
+	}
+	return format{}, false
+}
+
+// negotiate picks the response format from the Accept header, honouring
+// q-values. A missing header or a wildcard selects JSON.
+func negotiate(r *http.Request) (format, bool) {
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return formats[0], true
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, mr := range ranges {
+		if mr.mediaType == "*/*" || mr.mediaType == "application/*" {
+			return formats[0], true
+		}
+		if f, ok := formatFor(mr.mediaType); ok {
+			return f, true
+		}
+	}
+	return format{}, false
+}
+
+// Acceptable reports whether the Accept header of r allows a supported
+// media type.
+func Acceptable(r *http.Request) bool {
+	_, ok := negotiate(r)
+	return ok
+}
+
+// RequireAcceptable answers a request whose Accept header allows none of the
+// supported media types with 406 before the handler runs, so nothing is
+// changed for a response the client would refuse.
+func RequireAcceptable(next http.Handler) http.Handler {
+	responder := NewNegotiatingResponder()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !Acceptable(r) {
+			responder.Error(w, r, ErrNotAcceptable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// DecodeBody reads the request body into v according to its Content-Type,
+// which defaults to JSON.
+func DecodeBody(r *http.Request, v interface{}) error {
+	f := formats[0]
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return ErrUnsupportedMediaType.Wrap(err)
+		}
+		var ok bool
+		if f, ok = formatFor(mediaType); !ok {
+			return ErrUnsupportedMediaType
+		}
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+	if err := f.unmarshal(body, v); err != nil {
+		return apperror.Validation("invalid %s body: %v", f.mediaType, err)
+	}
+	return nil
+}
+
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+// marshalXML encodes v as an XML document. Slices and maps have no element
+// of their own, so they are wrapped in <items> and <map> root elements.
+func marshalXML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		start := xml.StartElement{Name: xml.Name{Local: "items"}}
+		if err := enc.EncodeToken(start); err != nil {
+			return nil, err
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+				return nil, err
+			}
+		}
+		if err := enc.EncodeToken(start.End()); err != nil {
+			return nil, err
+		}
+	case reflect.Map:
+		start := xml.StartElement{Name: xml.Name{Local: "map"}}
+		if err := enc.EncodeToken(start); err != nil {
+			return nil, err
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			entry := xml.StartElement{
+				Name: xml.Name{Local: "entry"},
+				Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: fmt.Sprint(k)}},
+			}
+			if err := enc.EncodeElement(rv.MapIndex(k).Interface(), entry); err != nil {
+				return nil, err
+			}
+		}
+		if err := enc.EncodeToken(start.End()); err != nil {
+			return nil, err
+		}
+	default:
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// unmarshalXML mirrors marshalXML: a slice is read from the children of
+// the root element, whatever the root is called.
+func unmarshalXML(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return xml.Unmarshal(data, v)
+	}
+
+	slice := rv.Elem()
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF && depth == 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				depth++
+				continue
+			}
+			elem := reflect.New(slice.Type().Elem())
+			if err := dec.DecodeElement(elem.Interface(), &t); err != nil {
+				return err
+			}
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// marshalYAML goes through JSON so YAML uses the same field names and
+// omissions as the json tags, without a second set of yaml tags.
+func marshalYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML; parsing it into a node keeps the field order.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+	return yaml.Marshal(&node)
+}
+
+// blockStyle drops the flow style YAML gives to parsed JSON, so output is
+// in the usual indented form. Tags are kept, so strings that look like
+// numbers stay quoted.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+func unmarshalYAML(data []byte, v interface{}) error {
+	var generic interface{}
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+	data, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
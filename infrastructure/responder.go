@@ -1,49 +1,128 @@
 package infrastructure
 
 import (
-	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
+	"petstore/internal/apperror"
+	"petstore/internal/dto"
+	"petstore/internal/logging"
+	"petstore/internal/middleware"
+	"strings"
 )
 
+// Responder writes responses in the representation the client asked for in
+// its Accept header: JSON, XML or YAML.
 type Responder interface {
-	OutputJSON(w http.ResponseWriter, responseData interface{})
+	// Output writes responseData with status 200.
+	Output(w http.ResponseWriter, r *http.Request, responseData interface{})
+	OutputStatus(w http.ResponseWriter, r *http.Request, status int, responseData interface{})
 
-	ErrorUnauthorized(w http.ResponseWriter, err error)
-	ErrorBadRequest(w http.ResponseWriter, err error)
-	ErrorNotFound(w http.ResponseWriter, err error)
-	ErrorInternal(w http.ResponseWriter, err error)
+	// Error writes err as an RFC 7807 problem with the status code of its
+	// apperror kind. Errors without a kind are reported as 500 without
+	// exposing their message.
+	Error(w http.ResponseWriter, r *http.Request, err error)
+
+	// ErrorBadRequest reports malformed input caught before reaching a
+	// service, such as an unparsable path parameter.
+	ErrorBadRequest(w http.ResponseWriter, r *http.Request, err error)
 }
 
-type JSONResponder struct{}
+type NegotiatingResponder struct{}
 
-func NewJSONResponder() Responder {
-	return &JSONResponder{}
+func NewNegotiatingResponder() Responder {
+	return &NegotiatingResponder{}
 }
 
-func (r *JSONResponder) OutputJSON(w http.ResponseWriter, responseData interface{}) {
-	w.Header().Set("Content-Type", "applicaton/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(responseData)
+func (rs *NegotiatingResponder) Output(w http.ResponseWriter, r *http.Request, responseData interface{}) {
+	rs.OutputStatus(w, r, http.StatusOK, responseData)
 }
 
-func (r *JSONResponder) ErrorUnauthorized(w http.ResponseWriter, err error) {
-	r.sendError(w, http.StatusUnauthorized, err)
+func (rs *NegotiatingResponder) OutputStatus(w http.ResponseWriter, r *http.Request, status int, responseData interface{}) {
+	f, ok := negotiate(r)
+	if !ok {
+		rs.Error(w, r, ErrNotAcceptable)
+		return
+	}
+
+	body, err := f.marshal(responseData)
+	if err != nil {
+		rs.Error(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", f.mediaType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	w.Write(body)
 }
 
-func (r *JSONResponder) ErrorBadRequest(w http.ResponseWriter, err error) {
-	r.sendError(w, http.StatusBadRequest, err)
+var kindStatus = map[apperror.Kind]int{
+	apperror.KindValidation:           http.StatusBadRequest,
+	apperror.KindUnauthorized:         http.StatusUnauthorized,
+	apperror.KindForbidden:            http.StatusForbidden,
+	apperror.KindNotFound:             http.StatusNotFound,
+	apperror.KindConflict:             http.StatusConflict,
+	apperror.KindTooManyRequests:      http.StatusTooManyRequests,
+	apperror.KindUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	apperror.KindNotAcceptable:        http.StatusNotAcceptable,
 }
 
-func (r *JSONResponder) ErrorNotFound(w http.ResponseWriter, err error) {
-	r.sendError(w, http.StatusNotFound, err)
+func (rs *NegotiatingResponder) Error(w http.ResponseWriter, r *http.Request, err error) {
+	// Report the message of the domain error itself, not the context
+	// wrapped around it on the way up.
+	var domain interface {
+		error
+		Kind() apperror.Kind
+	}
+	if errors.As(err, &domain) {
+		if status, ok := kindStatus[domain.Kind()]; ok {
+			problem := newProblem(r, status, domain.Error())
+			problem.Type = problemTypeBase + strings.ReplaceAll(domain.Kind().String(), " ", "-")
+			problem.Errors = apperror.FieldsOf(domain)
+			writeProblem(w, problemFormat(r), problem)
+			return
+		}
+	}
+	logging.FromContext(r.Context()).Error("internal error", "err", err)
+	writeProblem(w, problemFormat(r), newProblem(r, http.StatusInternalServerError, ""))
 }
 
-func (r *JSONResponder) ErrorInternal(w http.ResponseWriter, err error) {
-	r.sendError(w, http.StatusInternalServerError, err)
+func (rs *NegotiatingResponder) ErrorBadRequest(w http.ResponseWriter, r *http.Request, err error) {
+	writeProblem(w, problemFormat(r), newProblem(r, http.StatusBadRequest, err.Error()))
 }
 
-func (r *JSONResponder) sendError(w http.ResponseWriter, status int, err error) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+// problemTypeBase prefixes the problem type of domain errors, e.g.
+// /problems/not-found. Other errors use about:blank as RFC 7807 suggests.
+const problemTypeBase = "/problems/"
+
+func newProblem(r *http.Request, status int, detail string) dto.Problem {
+	return dto.Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: middleware.GetRequestID(r.Context()),
+	}
+}
+
+// problemFormat falls back to JSON rather than answering an error with 406.
+func problemFormat(r *http.Request) format {
+	if f, ok := negotiate(r); ok {
+		return f
+	}
+	return formats[0]
+}
+
+func writeProblem(w http.ResponseWriter, f format, problem dto.Problem) {
+	body, err := f.marshal(problem)
+	if err != nil {
+		slog.Error("encoding problem failed", "media_type", f.mediaType, "err", err)
+		f = formats[0]
+		body, _ = f.marshal(problem)
+	}
+	w.Header().Set("Content-Type", f.problemType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(problem.Status)
+	w.Write(body)
 }
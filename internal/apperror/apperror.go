@@ -0,0 +1,119 @@
+// Package apperror defines the domain errors services and repositories
+// return, classified by kind so the HTTP layer can map them to status codes
+// in one place.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindTooManyRequests
+	KindUnsupportedMediaType
+	KindNotAcceptable
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindValidation:
+		return "validation"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	case KindNotFound:
+		return "not found"
+	case KindConflict:
+		return "conflict"
+	case KindTooManyRequests:
+		return "too many requests"
+	case KindUnsupportedMediaType:
+		return "unsupported media type"
+	case KindNotAcceptable:
+		return "not acceptable"
+	}
+	return "internal"
+}
+
+// Error is a domain error of a given kind. Its message is meant for API
+// clients; the optional cause is kept for errors.Is/As and logging only.
+type Error struct {
+	kind   Kind
+	msg    string
+	cause  error
+	fields []FieldError
+}
+
+func New(kind Kind, format string, args ...interface{}) *Error {
+	return &Error{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+func NotFound(format string, args ...interface{}) *Error {
+	return New(KindNotFound, format, args...)
+}
+
+func Conflict(format string, args ...interface{}) *Error {
+	return New(KindConflict, format, args...)
+}
+
+func Validation(format string, args ...interface{}) *Error {
+	return New(KindValidation, format, args...)
+}
+
+func Unauthorized(format string, args ...interface{}) *Error {
+	return New(KindUnauthorized, format, args...)
+}
+
+func Forbidden(format string, args ...interface{}) *Error {
+	return New(KindForbidden, format, args...)
+}
+
+// Wrap returns a copy of e with cause attached.
+func (e *Error) Wrap(cause error) *Error {
+	c := *e
+	c.cause = cause
+	return &c
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+func (e *Error) Kind() Kind {
+	return e.kind
+}
+
+// Fields lists the invalid fields of a validation error.
+func (e *Error) Fields() []FieldError {
+	return e.fields
+}
+
+// Is makes a wrapped copy match the error it was made from, so sentinel
+// errors still work with errors.Is after Wrap.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.kind == e.kind && t.msg == e.msg
+}
+
+// KindOf returns the kind of the first error in err's chain that has one.
+// Errors without a kind are internal.
+func KindOf(err error) Kind {
+	var k interface{ Kind() Kind }
+	if errors.As(err, &k) {
+		return k.Kind()
+	}
+	return KindInternal
+}
@@ -0,0 +1,57 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Machine-readable codes for FieldError. Validators may use more specific
+// codes where clients need to tell problems apart.
+const (
+	CodeRequired      = "required"
+	CodeInvalid       = "invalid"
+	CodeTooShort      = "too_short"
+	CodeTooLong       = "too_long"
+	CodeOutOfRange    = "out_of_range"
+	CodeNotAllowed    = "not_allowed"
+	CodeInvalidFormat = "invalid_format"
+	CodeNotInFuture   = "not_in_future"
+)
+
+// FieldError describes one invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`
+	Code    string `json:"code" xml:"code"`
+	Message string `json:"message" xml:"message"`
+}
+
+// FieldErrors collects every invalid field so a request is rejected with
+// all of its problems at once instead of the first one.
+type FieldErrors []FieldError
+
+func (f *FieldErrors) Add(field, code, format string, args ...interface{}) {
+	*f = append(*f, FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns nil if no field is invalid, otherwise a validation error
+// listing the fields.
+func (f FieldErrors) Err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(f))
+	for i, fe := range f {
+		msgs[i] = fe.Message
+	}
+	return &Error{kind: KindValidation, msg: strings.Join(msgs, "; "), fields: f}
+}
+
+// FieldsOf returns the invalid fields reported anywhere in err's chain.
+func FieldsOf(err error) []FieldError {
+	var e interface{ Fields() []FieldError }
+	if errors.As(err, &e) {
+		return e.Fields()
+	}
+	return nil
+}
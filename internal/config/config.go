@@ -0,0 +1,196 @@
+// Package config holds the service configuration. Settings are resolved from,
+// in increasing order of precedence, built-in defaults, an optional YAML or
+// TOML file, environment variables (also read from an optional .env file)
+// and command-line flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Storage  string         `yaml:"storage" env:"STORAGE"`
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	JWT      JWTConfig      `yaml:"jwt"`
+	Log      LogConfig      `yaml:"log"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+	Mail     MailConfig     `yaml:"mail"`
+	Password PasswordConfig `yaml:"password"`
+	OIDC     OIDCConfig     `yaml:"oidc"`
+}
+
+func Default() Config {
+	return Config{
+		Storage: StoragePostgres,
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			ShutdownTimeout: 5 * time.Second,
+			DrainDelay:      5 * time.Second,
+			MaxBatchSize:    100,
+		},
+		Database: DatabaseConfig{
+			Host:             "localhost",
+			Port:             5432,
+			User:             "postgres",
+			Name:             "postgres",
+			SSLMode:          "disable",
+			ApplicationName:  "petstore",
+			ConnectTimeout:   5 * time.Second,
+			StatementTimeout: 30 * time.Second,
+			QueryTimeout:     10 * time.Second,
+			Pool: DatabasePoolConfig{
+				MaxOpenConns:    25,
+				MaxIdleConns:    10,
+				ConnMaxLifetime: 30 * time.Minute,
+				ConnMaxIdleTime: 5 * time.Minute,
+			},
+			Retry: DatabaseRetryConfig{
+				Attempts:       10,
+				InitialBackoff: 250 * time.Millisecond,
+				MaxBackoff:     10 * time.Second,
+			},
+		},
+		JWT: JWTConfig{
+			AccessTokenTTL: time.Hour,
+		},
+		Log: LogConfig{
+			Level:  slog.LevelInfo,
+			Format: LogFormatJSON,
+		},
+		Tracing: TracingConfig{
+			Exporter:    TraceExporterNone,
+			ServiceName: "petstore",
+			SampleRatio: 1,
+		},
+		Mail: MailConfig{
+			SMTPPort:   "587",
+			From:       "petstore@localhost",
+			AppBaseURL: "http://localhost:8080",
+		},
+		Password: PasswordConfig{
+			MinLength:     8,
+			MaxLength:     72,
+			CheckBreached: true,
+			Algorithm:     PasswordAlgorithmBcrypt,
+			BcryptCost:    10,
+			Argon2Time:    1,
+			Argon2Memory:  64 * 1024,
+			Argon2Threads: 4,
+		},
+		OIDC: OIDCConfig{
+			RedirectURL: "http://localhost:8080/auth/oidc/callback",
+			GroupsClaim: "groups",
+			DefaultRole: "customer",
+			RoleMapping: map[string]string{},
+		},
+	}
+}
+
+// Validate reports every invalid setting at once, so that a broken
+// deployment can be fixed in one go.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(oneOf(c.Storage, StoragePostgres, StorageMemory),
+		"storage %q must be %s or %s", c.Storage, StoragePostgres, StorageMemory)
+
+	check(c.Server.Addr != "", "server.addr is required")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.DrainDelay >= 0, "server.drain_delay must not be negative")
+	check(c.Server.MaxBatchSize > 0, "server.max_batch_size must be positive")
+
+	check(c.Database.Host != "", "database.host is required")
+	check(c.Database.Port > 0 && c.Database.Port <= 65535, "database.port must be between 1 and 65535")
+	check(c.Database.Name != "", "database.name is required")
+	check(oneOf(c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full"),
+		"database.sslmode %q is not a libpq sslmode", c.Database.SSLMode)
+	check(c.Database.ConnectTimeout >= 0, "database.connect_timeout must not be negative")
+	check(c.Database.StatementTimeout >= 0, "database.statement_timeout must not be negative")
+	check(c.Database.QueryTimeout >= 0, "database.query_timeout must not be negative")
+	check(c.Database.Pool.MaxOpenConns >= 0, "database.pool.max_open_conns must not be negative")
+	check(c.Database.Pool.MaxIdleConns >= 0, "database.pool.max_idle_conns must not be negative")
+	check(c.Database.Pool.MaxOpenConns == 0 || c.Database.Pool.MaxIdleConns <= c.Database.Pool.MaxOpenConns,
+		"database.pool.max_idle_conns must not exceed database.pool.max_open_conns")
+	check(c.Database.Pool.ConnMaxLifetime >= 0, "database.pool.conn_max_lifetime must not be negative")
+	check(c.Database.Pool.ConnMaxIdleTime >= 0, "database.pool.conn_max_idle_time must not be negative")
+	check(c.Database.Retry.Attempts >= 1, "database.retry.attempts must be at least 1")
+	check(c.Database.Retry.InitialBackoff > 0, "database.retry.initial_backoff must be positive")
+	check(c.Database.Retry.MaxBackoff >= c.Database.Retry.InitialBackoff,
+		"database.retry.max_backoff must not be less than database.retry.initial_backoff")
+
+	check(c.JWT.Secret == "" || len(c.JWT.Secret) >= minJWTSecretLength,
+		"jwt.secret must be at least %d characters", minJWTSecretLength)
+	check(c.JWT.AccessTokenTTL > 0, "jwt.access_token_ttl must be positive")
+
+	check(oneOf(c.Log.Format, LogFormatJSON, LogFormatText),
+		"log.format %q must be %s or %s", c.Log.Format, LogFormatJSON, LogFormatText)
+
+	check(oneOf(c.Tracing.Exporter, TraceExporterNone, TraceExporterOTLP, TraceExporterConsole),
+		"tracing.exporter %q must be %s, %s or %s", c.Tracing.Exporter, TraceExporterOTLP, TraceExporterConsole, TraceExporterNone)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	check(oneOf(c.Mail.Driver, MailDriverOutbox, MailDriverSMTP),
+		"mail.driver %q must be %s or %s", c.Mail.Driver, MailDriverOutbox, MailDriverSMTP)
+	check(c.Mail.PasswordResetURL == "" || isAbsoluteURL(c.Mail.PasswordResetURL),
+		"mail.password_reset_url must be an absolute URL")
+	check(c.Mail.Driver != MailDriverSMTP || c.Mail.SMTPHost != "", "mail.smtp_host is required for the smtp mail driver")
+
+	check(oneOf(c.Password.Algorithm, PasswordAlgorithmBcrypt, PasswordAlgorithmArgon2id),
+		"password.algorithm %q must be %s or %s", c.Password.Algorithm, PasswordAlgorithmBcrypt, PasswordAlgorithmArgon2id)
+	check(c.Password.MinLength <= c.Password.MaxLength, "password.min_length must not exceed password.max_length")
+
+	check(c.OIDC.IssuerURL == "" || c.OIDC.ClientID != "", "oidc.client_id is required with oidc.issuer_url")
+	check(!c.OIDC.Mock || c.Storage == StorageMemory, "oidc.mock is only allowed with storage %s", StorageMemory)
+
+	return errors.Join(errs...)
+}
+
+// WriteRedacted writes the configuration as YAML with secrets masked.
+func (c Config) WriteRedacted(w io.Writer) error {
+	redacted := c
+	for _, f := range settings(&redacted) {
+		if f.secret && f.value.String() != "" {
+			f.value.SetString(redactedValue)
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(redacted); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+const redactedValue = "REDACTED"
+
+func isAbsoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func oneOf(v string, allowed ...string) bool {
+	for _, a := range allowed {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
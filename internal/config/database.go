@@ -0,0 +1,76 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" env:"DB_HOST"`
+	Port     int    `yaml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE"`
+
+	// ApplicationName shows up in pg_stat_activity and the server logs.
+	ApplicationName string `yaml:"application_name" env:"DB_APPLICATION_NAME"`
+	// ConnectTimeout limits each connection attempt, zero waits indefinitely.
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+	// StatementTimeout makes the server cancel statements running longer,
+	// also those whose client went away. Zero disables it.
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT"`
+	// QueryTimeout bounds each repository call on the client, zero disables it.
+	QueryTimeout time.Duration `yaml:"query_timeout" env:"DB_QUERY_TIMEOUT"`
+
+	Pool  DatabasePoolConfig  `yaml:"pool"`
+	Retry DatabaseRetryConfig `yaml:"retry"`
+}
+
+// DatabasePoolConfig sizes the connection pool. Zero means unlimited, as in
+// database/sql.
+type DatabasePoolConfig struct {
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+}
+
+// DatabaseRetryConfig controls how long startup waits for the database. The
+// delay between attempts doubles from InitialBackoff up to MaxBackoff.
+type DatabaseRetryConfig struct {
+	Attempts       int           `yaml:"attempts" env:"DB_CONNECT_ATTEMPTS"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"DB_CONNECT_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"DB_CONNECT_MAX_BACKOFF"`
+}
+
+// DSN returns the connection URL, escaping credentials as needed. Timeouts
+// are rounded up to what libpq and the server accept: seconds for the
+// connect timeout, milliseconds for the statement timeout.
+func (c DatabaseConfig) DSN() string {
+	params := url.Values{"sslmode": {c.SSLMode}}
+	if c.ApplicationName != "" {
+		params.Set("application_name", c.ApplicationName)
+	}
+	if c.ConnectTimeout > 0 {
+		params.Set("connect_timeout", strconv.FormatInt(ceilUnits(c.ConnectTimeout, time.Second), 10))
+	}
+	if c.StatementTimeout > 0 {
+		params.Set("statement_timeout", strconv.FormatInt(ceilUnits(c.StatementTimeout, time.Millisecond), 10))
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:     "/" + c.Name,
+		RawQuery: params.Encode(),
+	}
+	return u.String()
+}
+
+func ceilUnits(d, unit time.Duration) int64 {
+	return int64((d + unit - 1) / unit)
+}
@@ -1,9 +1,55 @@
 package config
 
-import "github.com/go-chi/jwtauth"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+)
+
+// minJWTSecretLength is the HS256 key size; shorter secrets are guessable.
+const minJWTSecretLength = 32
+
+type JWTConfig struct {
+	Secret string `yaml:"secret" env:"JWT_SECRET" secret:"true"`
+	// ChallengeSecret signs two-factor challenge tokens. When empty it is
+	// derived from Secret.
+	ChallengeSecret string `yaml:"challenge_secret" env:"JWT_CHALLENGE_SECRET" secret:"true"`
+	// AccessTokenTTL is how long an access token is accepted after it was
+	// issued.
+	AccessTokenTTL time.Duration `yaml:"access_token_ttl" env:"JWT_ACCESS_TOKEN_TTL"`
+}
 
 var TokenAuth *jwtauth.JWTAuth
 
-func InitJWT() {
-	TokenAuth = jwtauth.New("HS256", []byte("supersecretkey"), nil)
+// ChallengeAuth signs the short-lived tokens handed out between the password
+// and the second factor of a two-step login. It uses its own key so that a
+// challenge token is never accepted as an access token.
+var ChallengeAuth *jwtauth.JWTAuth
+
+// AccessTokenTTL is the lifetime of the access tokens signed with TokenAuth.
+var AccessTokenTTL time.Duration
+
+// RequireSecret reports a missing secret. Only the commands that sign or
+// verify tokens call it, so that e.g. migrations run without one.
+func (c JWTConfig) RequireSecret() error {
+	if c.Secret == "" {
+		return errors.New("jwt.secret is required")
+	}
+	return nil
+}
+
+func InitJWT(cfg JWTConfig) {
+	challengeSecret := []byte(cfg.ChallengeSecret)
+	if len(challengeSecret) == 0 {
+		mac := hmac.New(sha256.New, []byte(cfg.Secret))
+		mac.Write([]byte("2fa-challenge"))
+		challengeSecret = mac.Sum(nil)
+	}
+
+	TokenAuth = jwtauth.New("HS256", []byte(cfg.Secret), nil)
+	ChallengeAuth = jwtauth.New("HS256", challengeSecret, nil)
+	AccessTokenTTL = cfg.AccessTokenTTL
 }
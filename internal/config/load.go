@@ -0,0 +1,318 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Flags are the command-line flags of the configuration: --config,
+// --print-config and one flag per setting, named after its dotted file key
+// with dashes, e.g. --server.read-timeout.
+type Flags struct {
+	File        string
+	PrintConfig bool
+
+	values map[string]*flagValue
+}
+
+// flagValue remembers whether the flag was given, so that only explicit flags
+// override the other sources.
+type flagValue struct {
+	raw string
+	set bool
+}
+
+func (v *flagValue) String() string { return v.raw }
+
+func (v *flagValue) Set(s string) error {
+	v.raw, v.set = s, true
+	return nil
+}
+
+// NewFlags registers the configuration flags on fs.
+func NewFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{values: make(map[string]*flagValue)}
+	fs.StringVar(&f.File, "config", os.Getenv("CONFIG_FILE"), "path of a YAML or TOML config file (env CONFIG_FILE)")
+	fs.BoolVar(&f.PrintConfig, "print-config", false, "print the resolved configuration with secrets redacted and exit")
+
+	cfg := Default()
+	for _, s := range settings(&cfg) {
+		v := &flagValue{}
+		f.values[s.key] = v
+		usage := "sets " + s.key
+		if s.env != "" {
+			usage += " (env " + s.env + ")"
+		}
+		fs.Var(v, strings.ReplaceAll(s.key, "_", "-"), usage)
+	}
+	return f
+}
+
+// Load resolves the configuration and validates it. flags may be nil when
+// there is no command line, e.g. in tests.
+func Load(flags *Flags) (Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("error loading .env file: %w", err)
+	}
+
+	cfg := Default()
+	file := os.Getenv("CONFIG_FILE")
+	if flags != nil {
+		file = flags.File
+	}
+	if file != "" {
+		if err := loadFile(&cfg, file); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := loadEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+	if flags != nil {
+		if err := flags.apply(&cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	cfg.normalize()
+	return cfg, cfg.Validate()
+}
+
+// normalize fills in settings whose default depends on other settings.
+func (c *Config) normalize() {
+	c.Storage = strings.ToLower(c.Storage)
+	c.Log.Format = strings.ToLower(c.Log.Format)
+	c.Tracing.Exporter = strings.ToLower(c.Tracing.Exporter)
+	if c.Mail.Driver == "" {
+		c.Mail.Driver = MailDriverOutbox
+		if c.Mail.SMTPHost != "" {
+			c.Mail.Driver = MailDriverSMTP
+		}
+	}
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	byKey := make(map[string]setting)
+	for _, s := range settings(cfg) {
+		byKey[s.key] = s
+	}
+	return applyFileValues(byKey, "", values)
+}
+
+func applyFileValues(byKey map[string]setting, prefix string, values map[string]interface{}) error {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := prefix + k
+		if s, ok := byKey[key]; ok {
+			if err := setValue(s.value, values[k]); err != nil {
+				return fmt.Errorf("config file: %s: %w", key, err)
+			}
+			continue
+		}
+		section, ok := values[k].(map[string]interface{})
+		if !ok || !hasPrefix(byKey, key+".") {
+			return fmt.Errorf("config file: unknown setting %s", key)
+		}
+		if err := applyFileValues(byKey, key+".", section); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasPrefix(byKey map[string]setting, prefix string) bool {
+	for key := range byKey {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadEnv applies the environment. Empty variables count as unset.
+func loadEnv(cfg *Config) error {
+	for _, s := range settings(cfg) {
+		if s.env == "" {
+			continue
+		}
+		if raw := os.Getenv(s.env); raw != "" {
+			if err := setValue(s.value, raw); err != nil {
+				return fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (f *Flags) apply(cfg *Config) error {
+	for _, s := range settings(cfg) {
+		if v := f.values[s.key]; v != nil && v.set {
+			if err := setValue(s.value, v.raw); err != nil {
+				return fmt.Errorf("--%s: %w", strings.ReplaceAll(s.key, "_", "-"), err)
+			}
+		}
+	}
+	return nil
+}
+
+// setting is one leaf of Config, addressed by its dotted file key.
+type setting struct {
+	key    string
+	env    string
+	secret bool
+	value  reflect.Value
+}
+
+func settings(cfg *Config) []setting {
+	return collectSettings(reflect.ValueOf(cfg).Elem(), "", nil)
+}
+
+func collectSettings(v reflect.Value, prefix string, out []setting) []setting {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := prefix + field.Tag.Get("yaml")
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			out = collectSettings(fv, key+".", out)
+			continue
+		}
+		out = append(out, setting{
+			key:    key,
+			env:    field.Tag.Get("env"),
+			secret: field.Tag.Get("secret") == "true",
+			value:  fv,
+		})
+	}
+	return out
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue stores raw in v. raw is a string from the environment or a flag,
+// or a value decoded from the config file.
+func setValue(v reflect.Value, raw interface{}) error {
+	switch v.Kind() {
+	case reflect.Slice:
+		var items []string
+		switch r := raw.(type) {
+		case []interface{}:
+			for _, item := range r {
+				items = append(items, fmt.Sprint(item))
+			}
+		default:
+			items = strings.Fields(strings.ReplaceAll(fmt.Sprint(r), ",", " "))
+		}
+		v.Set(reflect.ValueOf(items))
+		return nil
+	case reflect.Map:
+		m := make(map[string]string)
+		switch r := raw.(type) {
+		case map[string]interface{}:
+			for k, item := range r {
+				m[k] = fmt.Sprint(item)
+			}
+		default:
+			for _, pair := range strings.Split(fmt.Sprint(r), ",") {
+				k, item, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || k == "" || item == "" {
+					return fmt.Errorf("%q is not of the form key=value", pair)
+				}
+				m[k] = item
+			}
+		}
+		v.Set(reflect.ValueOf(m))
+		return nil
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		switch raw.(type) {
+		case bool, int, int64, uint64, float64:
+			s = fmt.Sprint(raw)
+		default:
+			return fmt.Errorf("expected a single value, got %T", raw)
+		}
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, use a value like 10s", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", s)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package config
+
+import "log/slog"
+
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+type LogConfig struct {
+	Level  slog.Level `yaml:"level" env:"LOG_LEVEL"`
+	Format string     `yaml:"format" env:"LOG_FORMAT"`
+}
@@ -0,0 +1,23 @@
+package config
+
+const (
+	MailDriverOutbox = "outbox"
+	MailDriverSMTP   = "smtp"
+)
+
+type MailConfig struct {
+	// Driver defaults to smtp when an SMTP host is set and to outbox
+	// otherwise.
+	Driver       string `yaml:"driver" env:"MAIL_DRIVER"`
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     string `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	From         string `yaml:"from" env:"MAIL_FROM"`
+	OutboxDir    string `yaml:"outbox_dir" env:"MAIL_OUTBOX_DIR"`
+	AppBaseURL   string `yaml:"app_base_url" env:"APP_BASE_URL"`
+	// PasswordResetURL is the page, usually of a frontend, where users choose
+	// a new password. Reset mails link to it with the token in the token
+	// query parameter; without it they carry only the token.
+	PasswordResetURL string `yaml:"password_reset_url" env:"PASSWORD_RESET_URL"`
+}
@@ -0,0 +1,22 @@
+package config
+
+type OIDCConfig struct {
+	IssuerURL    string   `yaml:"issuer_url" env:"OIDC_ISSUER_URL"`
+	ClientID     string   `yaml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret string   `yaml:"client_secret" env:"OIDC_CLIENT_SECRET" secret:"true"`
+	RedirectURL  string   `yaml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+	Scopes       []string `yaml:"scopes" env:"OIDC_SCOPES"`
+	GroupsClaim  string   `yaml:"groups_claim" env:"OIDC_GROUPS_CLAIM"`
+	// RoleMapping maps IdP groups to roles. In the environment it has the
+	// form "group=role,group=role".
+	RoleMapping map[string]string `yaml:"role_mapping" env:"OIDC_ROLE_MAPPING"`
+	DefaultRole string            `yaml:"default_role" env:"OIDC_DEFAULT_ROLE"`
+	// Mock starts the in-process mock provider from oidc/oidctest instead of
+	// talking to a real IdP. It signs anyone in without credentials, so it is
+	// refused unless the storage is memory.
+	Mock bool `yaml:"mock" env:"OIDC_MOCK"`
+}
+
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != "" || c.Mock
+}
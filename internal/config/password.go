@@ -0,0 +1,27 @@
+package config
+
+const (
+	PasswordAlgorithmBcrypt   = "bcrypt"
+	PasswordAlgorithmArgon2id = "argon2id"
+)
+
+type PasswordConfig struct {
+	MinLength     int  `yaml:"min_length" env:"PASSWORD_MIN_LENGTH"`
+	MaxLength     int  `yaml:"max_length" env:"PASSWORD_MAX_LENGTH"`
+	RequireUpper  bool `yaml:"require_upper" env:"PASSWORD_REQUIRE_UPPER"`
+	RequireLower  bool `yaml:"require_lower" env:"PASSWORD_REQUIRE_LOWER"`
+	RequireDigit  bool `yaml:"require_digit" env:"PASSWORD_REQUIRE_DIGIT"`
+	RequireSymbol bool `yaml:"require_symbol" env:"PASSWORD_REQUIRE_SYMBOL"`
+	// CheckBreached rejects passwords found in the bundled common password
+	// list and, if set, in BreachedListFile (one password per line).
+	CheckBreached    bool   `yaml:"check_breached" env:"PASSWORD_CHECK_BREACHED"`
+	BreachedListFile string `yaml:"breached_list_file" env:"PASSWORD_BREACHED_LIST_FILE"`
+
+	// Algorithm is used for new hashes. Stored hashes of the other algorithm
+	// still verify and are rehashed on the next successful login.
+	Algorithm     string `yaml:"algorithm" env:"PASSWORD_HASH_ALGORITHM"`
+	BcryptCost    int    `yaml:"bcrypt_cost" env:"BCRYPT_COST"`
+	Argon2Time    uint32 `yaml:"argon2_time" env:"ARGON2_TIME"`
+	Argon2Memory  uint32 `yaml:"argon2_memory_kb" env:"ARGON2_MEMORY_KB"` // KiB
+	Argon2Threads uint8  `yaml:"argon2_threads" env:"ARGON2_THREADS"`
+}
@@ -0,0 +1,16 @@
+package config
+
+import "time"
+
+type ServerConfig struct {
+	Addr            string        `yaml:"addr" env:"SERVER_ADDR"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	// DrainDelay is how long the server keeps serving after /readyz starts
+	// failing on shutdown, giving load balancers time to notice.
+	DrainDelay time.Duration `yaml:"drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`
+	// MaxBatchSize caps the users in one bulk creation request, each of
+	// which costs a password hash.
+	MaxBatchSize int `yaml:"max_batch_size" env:"SERVER_MAX_BATCH_SIZE"`
+}
@@ -0,0 +1,8 @@
+package config
+
+const (
+	StoragePostgres = "postgres"
+	// StorageMemory keeps all data in the process, for demos and tests. It is
+	// lost on exit.
+	StorageMemory = "memory"
+)
@@ -0,0 +1,17 @@
+package config
+
+// Trace exporters, named like the values of the standard
+// OTEL_TRACES_EXPORTER variable.
+const (
+	TraceExporterNone    = "none"
+	TraceExporterOTLP    = "otlp"
+	TraceExporterConsole = "console"
+)
+
+// TracingConfig selects where spans go. The OTLP exporter reads its endpoint,
+// headers and timeout from the standard OTEL_EXPORTER_OTLP_* variables.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"OTEL_TRACES_EXPORTER"`
+	ServiceName string  `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG"`
+}
@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"petstore/infrastructure"
+	"petstore/internal/dto"
+	"petstore/internal/middleware"
+	"petstore/internal/model"
+	"petstore/internal/service"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type AdminController struct {
+	Service   service.UserService
+	Responder infrastructure.Responder
+}
+
+func RegisterAdminRoutes(r chi.Router, ac *AdminController, auth func(http.Handler) http.Handler) {
+	r.Route("/admin/users", func(r chi.Router) {
+		r.Use(auth, middleware.RequireInteractiveLogin, middleware.RequireRole(model.RoleAdmin))
+		r.Get("/", listUsers(ac))
+		r.Route("/{username}", func(r chi.Router) {
+			r.Get("/", getAdminUser(ac))
+			r.Post("/suspend", suspendUser(ac))
+			r.Post("/reactivate", reactivateUser(ac))
+			r.Post("/force-password-reset", forcePasswordReset(ac))
+		})
+	})
+}
+
+// ListUsers godoc
+// @Summary      List users
+// @Description  Admin only. Searches username, email and name case-insensitively.
+// @Tags         admin
+// @Produce      json,xml,application/yaml
+// @Param        q query string false "Search text"
+// @Param        status query int false "Filter by user status (0 unverified, 1 active, 2 erased, 3 suspended)"
+// @Param        limit query int false "Page size, at most 100" default(20)
+// @Param        offset query int false "Number of users to skip" default(0)
+// @Success      200 {object} dto.UserPage
+// @Security     ApiKeyAuth
+// @Router       /admin/users [get]
+func listUsers(ac *AdminController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		search := model.UserSearch{Query: q.Get("q")}
+
+		var err error
+		if search.Limit, err = queryInt(q.Get("limit")); err != nil {
+			ac.Responder.ErrorBadRequest(w, r, fmt.Errorf("invalid limit"))
+			return
+		}
+		if search.Offset, err = queryInt(q.Get("offset")); err != nil {
+			ac.Responder.ErrorBadRequest(w, r, fmt.Errorf("invalid offset"))
+			return
+		}
+		if s := q.Get("status"); s != "" {
+			status, err := strconv.Atoi(s)
+			if err != nil {
+				ac.Responder.ErrorBadRequest(w, r, fmt.Errorf("invalid status"))
+				return
+			}
+			search.Status = &status
+		}
+
+		users, total, err := ac.Service.SearchUsers(r.Context(), search)
+		if err != nil {
+			ac.Responder.Error(w, r, err)
+			return
+		}
+
+		if search.Limit == 0 {
+			search.Limit = service.DefaultUserPageSize
+		}
+		ac.Responder.Output(w, r, dto.NewUserPage(users, total, search.Limit, search.Offset))
+	}
+}
+
+// GetAdminUser godoc
+// @Summary      Get user details
+// @Description  Admin only. Includes the last login time.
+// @Tags         admin
+// @Produce      json,xml,application/yaml
+// @Param        username path string true "The user to fetch"
+// @Success      200 {object} dto.AdminUserResponse
+// @Failure      404 {object} dto.Problem "user not found"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{username} [get]
+func getAdminUser(ac *AdminController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := ac.Service.FindUserByUsername(r.Context(), chi.URLParam(r, "username"))
+		if err != nil {
+			ac.Responder.Error(w, r, err)
+			return
+		}
+
+		ac.Responder.Output(w, r, dto.NewAdminUserResponse(user))
+	}
+}
+
+// SuspendUser godoc
+// @Summary      Suspend a user
+// @Description  Admin only. Blocks password, two-factor, SSO and API key logins until the user is reactivated.
+// @Tags         admin
+// @Produce      json,xml,application/yaml
+// @Param        username path string true "The user to suspend"
+// @Success      200 {object} dto.AdminUserResponse
+// @Failure      404 {object} dto.Problem "user not found"
+// @Failure      409 {object} dto.Problem "user cannot be suspended"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{username}/suspend [post]
+func suspendUser(ac *AdminController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		admin := middleware.CetUserFromContext(r.Context())
+
+		user, err := ac.Service.SuspendUser(r.Context(), chi.URLParam(r, "username"), admin)
+		if err != nil {
+			ac.Responder.Error(w, r, err)
+			return
+		}
+
+		ac.Responder.Output(w, r, dto.NewAdminUserResponse(user))
+	}
+}
+
+// ReactivateUser godoc
+// @Summary      Reactivate a suspended user
+// @Description  Admin only.
+// @Tags         admin
+// @Produce      json,xml,application/yaml
+// @Param        username path string true "The user to reactivate"
+// @Success      200 {object} dto.AdminUserResponse
+// @Failure      404 {object} dto.Problem "user not found"
+// @Failure      409 {object} dto.Problem "user is not suspended"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{username}/reactivate [post]
+func reactivateUser(ac *AdminController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := ac.Service.ReactivateUser(r.Context(), chi.URLParam(r, "username"))
+		if err != nil {
+			ac.Responder.Error(w, r, err)
+			return
+		}
+
+		ac.Responder.Output(w, r, dto.NewAdminUserResponse(user))
+	}
+}
+
+// ForcePasswordReset godoc
+// @Summary      Force a password reset
+// @Description  Admin only. Invalidates the current password and emails the user a reset link.
+// @Tags         admin
+// @Produce      json,xml,application/yaml
+// @Param        username path string true "The user whose password is reset"
+// @Success      200 {object} model.ApiResponse "successful operation"
+// @Failure      404 {object} dto.Problem "user not found"
+// @Failure      409 {object} dto.Problem "user has no email address"
+// @Security     ApiKeyAuth
+// @Router       /admin/users/{username}/force-password-reset [post]
+func forcePasswordReset(ac *AdminController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := ac.Service.ForcePasswordReset(r.Context(), chi.URLParam(r, "username")); err != nil {
+			ac.Responder.Error(w, r, err)
+			return
+		}
+
+		ac.Responder.Output(w, r, model.ApiResponse{
+			Code:    http.StatusOK,
+			Type:    "success",
+			Message: "password has been reset and a reset link was sent",
+		})
+	}
+}
+
+func queryInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
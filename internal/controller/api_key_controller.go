@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"petstore/infrastructure"
+	"petstore/internal/middleware"
+	"petstore/internal/model"
+	"petstore/internal/service"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+type APIKeyController struct {
+	Service   service.APIKeyService
+	Responder infrastructure.Responder
+}
+
+func RegisterAPIKeyRoutes(r chi.Router, kc *APIKeyController) {
+	r.Route("/apikeys", func(r chi.Router) {
+		r.Use(middleware.RequireInteractiveLogin)
+		r.Post("/", createAPIKey(kc))
+		r.Get("/", listAPIKeys(kc))
+		r.Delete("/{keyId}", revokeAPIKey(kc))
+	})
+}
+
+// CreateAPIKey godoc
+// @Summary      Issue an API key
+// @Description  Issues a key for the logged in user, limited to the given scopes. The key is returned only once; send it in the X-API-Key header.
+// @Tags         apikeys
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
+// @Param        body body model.CreateAPIKeyRequest true "Key name and scopes"
+// @Success      201 {object} model.CreatedAPIKey
+// @Security     ApiKeyAuth
+// @Router       /apikeys [post]
+func createAPIKey(kc *APIKeyController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := middleware.CetUserFromContext(r.Context())
+
+		var req model.CreateAPIKeyRequest
+		if err := decodeBody(r, &req); err != nil {
+			kc.Responder.Error(w, r, err)
+			return
+		}
+
+		key, err := kc.Service.CreateKey(r.Context(), username, req)
+		if err != nil {
+			kc.Responder.Error(w, r, err)
+			return
+		}
+
+		kc.Responder.OutputStatus(w, r, http.StatusCreated, key)
+	}
+}
+
+// ListAPIKeys godoc
+// @Summary      List API keys
+// @Description  Lists the logged in user's keys, including revoked ones. Secrets are never returned.
+// @Tags         apikeys
+// @Produce      json,xml,application/yaml
+// @Success      200 {array} model.APIKey
+// @Security     ApiKeyAuth
+// @Router       /apikeys [get]
+func listAPIKeys(kc *APIKeyController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := middleware.CetUserFromContext(r.Context())
+
+		keys, err := kc.Service.ListKeys(r.Context(), username)
+		if err != nil {
+			kc.Responder.Error(w, r, err)
+			return
+		}
+
+		kc.Responder.Output(w, r, keys)
+	}
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Tags         apikeys
+// @Produce      json,xml,application/yaml
+// @Param        keyId path int true "ID of the key to revoke"
+// @Success      204 "key revoked"
+// @Security     ApiKeyAuth
+// @Router       /apikeys/{keyId} [delete]
+func revokeAPIKey(kc *APIKeyController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := middleware.CetUserFromContext(r.Context())
+
+		keyID, err := strconv.ParseInt(chi.URLParam(r, "keyId"), 10, 64)
+		if err != nil {
+			kc.Responder.ErrorBadRequest(w, r, fmt.Errorf("invalid key ID"))
+			return
+		}
+
+		if err := kc.Service.RevokeKey(r.Context(), username, keyID); err != nil {
+			kc.Responder.Error(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"net/http"
+	"petstore/infrastructure"
+	"petstore/internal/dto"
+	"petstore/internal/health"
+
+	"github.com/go-chi/chi"
+)
+
+type HealthController struct {
+	Checker   *health.Checker
+	Responder infrastructure.Responder
+}
+
+func RegisterHealthRoutes(r chi.Router, hc *HealthController) {
+	r.Get("/healthz", liveness(hc))
+	r.Get("/readyz", readiness(hc))
+}
+
+// Liveness godoc
+// @Summary      Liveness probe
+// @Description  Answers as long as the process is able to serve requests. Dependencies are not checked.
+// @Tags         health
+// @Produce      json,xml,application/yaml
+// @Success      200 {object} dto.HealthResponse
+// @Router       /healthz [get]
+func liveness(hc *HealthController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hc.Responder.Output(w, r, dto.HealthResponse{Status: dto.HealthStatusUp})
+	}
+}
+
+// Readiness godoc
+// @Summary      Readiness probe
+// @Description  Checks the database connection and that the schema is at the latest migration. Reports not ready while the server shuts down.
+// @Tags         health
+// @Produce      json,xml,application/yaml
+// @Success      200 {object} dto.HealthResponse
+// @Failure      503 {object} dto.HealthResponse "a component is down or the server is shutting down"
+// @Router       /readyz [get]
+func readiness(hc *HealthController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if hc.Checker.ShuttingDown() {
+			hc.Responder.OutputStatus(w, r, http.StatusServiceUnavailable, dto.HealthResponse{Status: dto.HealthStatusShuttingDown})
+			return
+		}
+
+		resp := dto.NewReadinessResponse(hc.Checker.Check(r.Context()))
+		status := http.StatusOK
+		if resp.Status != dto.HealthStatusUp {
+			status = http.StatusServiceUnavailable
+		}
+		hc.Responder.OutputStatus(w, r, status, resp)
+	}
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"petstore/infrastructure"
+	"petstore/internal/apperror"
+	"petstore/internal/logging"
+	"petstore/internal/model"
+	"petstore/internal/oidc"
+	"petstore/internal/service"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcStateTTL    = 10 * time.Minute
+)
+
+type OIDCController struct {
+	Provider  *oidc.Provider
+	Service   service.UserService
+	Responder infrastructure.Responder
+
+	mu      sync.Mutex
+	pending map[string]oidcLogin
+}
+
+type oidcLogin struct {
+	nonce     string
+	verifier  string
+	expiresAt time.Time
+}
+
+func RegisterOIDCRoutes(r chi.Router, oc *OIDCController) {
+	r.Route("/auth/oidc", func(r chi.Router) {
+		r.Get("/login", oidcLoginRedirect(oc))
+		r.Get("/callback", oidcCallback(oc))
+	})
+}
+
+// OIDCLogin godoc
+// @Summary      Sign in with the company identity provider
+// @Description  Redirects to the OpenID Connect provider. After sign-in the provider redirects back to /auth/oidc/callback.
+// @Tags         auth
+// @Success      302 "redirect to the identity provider"
+// @Router       /auth/oidc/login [get]
+func oidcLoginRedirect(oc *OIDCController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state, err := oidc.RandomString()
+		if err != nil {
+			oc.Responder.Error(w, r, err)
+			return
+		}
+		nonce, err := oidc.RandomString()
+		if err != nil {
+			oc.Responder.Error(w, r, err)
+			return
+		}
+		verifier, err := oidc.RandomString()
+		if err != nil {
+			oc.Responder.Error(w, r, err)
+			return
+		}
+
+		oc.savePending(state, oidcLogin{
+			nonce:     nonce,
+			verifier:  verifier,
+			expiresAt: time.Now().Add(oidcStateTTL),
+		})
+
+		// The cookie binds the state to this browser, so a callback URL
+		// crafted elsewhere can't log the victim into someone else's account.
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcStateCookie,
+			Value:    state,
+			Path:     "/auth/oidc",
+			MaxAge:   int(oidcStateTTL.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, oc.Provider.AuthCodeURL(state, nonce, oidc.CodeChallenge(verifier)), http.StatusFound)
+	}
+}
+
+// OIDCCallback godoc
+// @Summary      OpenID Connect callback
+// @Description  Completes the authorization code flow, provisions the user on first login and returns an access token.
+// @Tags         auth
+// @Produce      json,xml,application/yaml
+// @Param        code query string true "Authorization code"
+// @Param        state query string true "State from /auth/oidc/login"
+// @Success      200 {object} model.LoginResult "token"
+// @Router       /auth/oidc/callback [get]
+func oidcCallback(oc *OIDCController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if idpErr := q.Get("error"); idpErr != "" {
+			oc.Responder.Error(w, r, apperror.Unauthorized("identity provider returned %s: %s", idpErr, q.Get("error_description")))
+			return
+		}
+
+		state := q.Get("state")
+		cookie, err := r.Cookie(oidcStateCookie)
+		if state == "" || err != nil || cookie.Value != state {
+			oc.Responder.ErrorBadRequest(w, r, fmt.Errorf("invalid oidc state"))
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/auth/oidc", MaxAge: -1})
+
+		pending, ok := oc.takePending(state)
+		if !ok {
+			oc.Responder.ErrorBadRequest(w, r, fmt.Errorf("oidc login expired, start again"))
+			return
+		}
+
+		code := q.Get("code")
+		if code == "" {
+			oc.Responder.ErrorBadRequest(w, r, fmt.Errorf("code query parameter is required"))
+			return
+		}
+
+		rawIDToken, err := oc.Provider.Exchange(r.Context(), code, pending.verifier)
+		if err != nil {
+			logging.FromContext(r.Context()).Warn("exchanging oidc code failed", "err", err)
+			oc.Responder.Error(w, r, apperror.Unauthorized("failed to exchange authorization code"))
+			return
+		}
+
+		claims, err := oc.Provider.VerifyIDToken(r.Context(), rawIDToken, pending.nonce)
+		if err != nil {
+			oc.Responder.Error(w, r, err)
+			return
+		}
+
+		token, err := oc.Service.LoginExternal(r.Context(), model.ExternalIdentity{
+			Provider:      oc.Provider.Issuer(),
+			Subject:       claims.Subject,
+			Username:      claims.PreferredUsername,
+			Email:         claims.Email,
+			EmailVerified: claims.EmailVerified,
+			FirstName:     claims.GivenName,
+			LastName:      claims.FamilyName,
+			Role:          oc.Provider.Role(claims.Groups),
+		})
+		if err != nil {
+			oc.Responder.Error(w, r, err)
+			return
+		}
+
+		oc.Responder.Output(w, r, model.LoginResult{Token: token})
+	}
+}
+
+func (oc *OIDCController) savePending(state string, login oidcLogin) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	if oc.pending == nil {
+		oc.pending = make(map[string]oidcLogin)
+	}
+	now := time.Now()
+	for s, p := range oc.pending {
+		if p.expiresAt.Before(now) {
+			delete(oc.pending, s)
+		}
+	}
+	oc.pending[state] = login
+}
+
+func (oc *OIDCController) takePending(state string) (oidcLogin, bool) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	login, ok := oc.pending[state]
+	delete(oc.pending, state)
+	if !ok || login.expiresAt.Before(time.Now()) {
+		return oidcLogin{}, false
+	}
+	return login, true
+}
@@ -0,0 +1,249 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"petstore/infrastructure"
+	"petstore/internal/config"
+	"petstore/internal/controller"
+	"petstore/internal/mailer"
+	"petstore/internal/metrics"
+	"petstore/internal/model"
+	"petstore/internal/oidc"
+	"petstore/internal/oidc/oidctest"
+	"petstore/internal/repository"
+	"petstore/internal/repository/memory"
+	"petstore/internal/service"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+type oidcFixture struct {
+	app   *httptest.Server
+	idp   *oidctest.Provider
+	users repository.UserRepository
+}
+
+func newOIDCFixture(t *testing.T) *oidcFixture {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.JWT.Secret = "oidc-controller-test-secret-0123456789"
+	cfg.Mail.Driver = config.MailDriverOutbox
+	cfg.Mail.OutboxDir = t.TempDir()
+	cfg.Password.CheckBreached = false
+	config.InitJWT(cfg.JWT)
+
+	idp, err := oidctest.NewProvider("petstore", "petstore-secret", oidctest.User{
+		Subject:           "subject-1",
+		Email:             "jane.staff@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "jane.staff",
+		GivenName:         "Jane",
+		FamilyName:        "Staff",
+		Groups:            []string{"petstore-staff"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwords, err := service.NewPasswordManager(cfg.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userService := service.NewUserService(users, memory.NewTokenRepository(store), mail,
+		service.NewLoginThrottler(service.DefaultLoginThrottleConfig), passwords, metrics.New(),
+		cfg.Mail.AppBaseURL, "")
+
+	oc := &controller.OIDCController{Service: userService, Responder: infrastructure.NewNegotiatingResponder()}
+	r := chi.NewRouter()
+	controller.RegisterOIDCRoutes(r, oc)
+	app := httptest.NewServer(r)
+	t.Cleanup(app.Close)
+
+	oc.Provider, err = oidc.Discover(context.Background(), oidc.Config{
+		IssuerURL:    idp.Issuer(),
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  app.URL + "/auth/oidc/callback",
+		RoleMapping:  map[string]string{"petstore-staff": model.RoleStaff},
+		DefaultRole:  model.RoleCustomer,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &oidcFixture{app: app, idp: idp, users: users}
+}
+
+// client keeps cookies like a browser. With follow false it stops at the
+// first redirect.
+func (f *oidcFixture) client(t *testing.T, follow bool) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &http.Client{Jar: jar}
+	if !follow {
+		c.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	}
+	return c
+}
+
+// authorize starts a login and returns the authorization request the app
+// redirects to.
+func (f *oidcFixture) authorize(t *testing.T, c *http.Client) *url.URL {
+	t.Helper()
+	resp, err := c.Get(f.app.URL + "/auth/oidc/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("login: got status %d, want %d", resp.StatusCode, http.StatusFound)
+	}
+	location, err := resp.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
+
+// follow sends the authorization request to the provider and the resulting
+// callback to the app.
+func (f *oidcFixture) follow(t *testing.T, c *http.Client, authorize *url.URL) *http.Response {
+	t.Helper()
+	resp, err := c.Get(authorize.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := resp.Location()
+	if err != nil {
+		t.Fatalf("provider did not redirect back: %v", err)
+	}
+	resp, err = c.Get(callback.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestOIDCLoginProvisionsUser(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	resp, err := f.client(t, true).Get(f.app.URL + "/auth/oidc/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var result model.LoginResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Token == "" {
+		t.Fatal("no access token returned")
+	}
+
+	user, err := f.users.FindByExternalIdentity(context.Background(), f.idp.Issuer(), "subject-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "jane.staff" || user.Role != model.RoleStaff || user.Email != "jane.staff@example.com" {
+		t.Errorf("provisioned user = %+v", user)
+	}
+}
+
+func TestOIDCLoginSendsPKCEAndNonce(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	q := f.authorize(t, f.client(t, false)).Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Errorf("authorization request lacks a PKCE challenge: %v", q)
+	}
+	if q.Get("nonce") == "" || q.Get("state") == "" {
+		t.Errorf("authorization request lacks nonce or state: %v", q)
+	}
+}
+
+func TestOIDCCallbackRejectsTamperedRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		param  string
+		status int
+	}{
+		// The provider checks the code verifier against this challenge.
+		{"code challenge", "code_challenge", http.StatusUnauthorized},
+		// The ID token then carries a nonce the app did not issue.
+		{"nonce", "nonce", http.StatusUnauthorized},
+		// The state no longer matches the cookie of the browser.
+		{"state", "state", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOIDCFixture(t)
+			c := f.client(t, false)
+
+			authorize := f.authorize(t, c)
+			q := authorize.Query()
+			q.Set(tt.param, "tampered-"+q.Get(tt.param))
+			authorize.RawQuery = q.Encode()
+
+			resp := f.follow(t, c, authorize)
+			if resp.StatusCode != tt.status {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("got content type %q, want a problem", got)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackIsSingleUse(t *testing.T) {
+	f := newOIDCFixture(t)
+	c := f.client(t, false)
+
+	authorize := f.authorize(t, c)
+	resp, err := c.Get(authorize.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := resp.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []int{http.StatusOK, http.StatusBadRequest} {
+		req, _ := http.NewRequest(http.MethodGet, callback.String(), nil)
+		// Replay with the state cookie even though the first callback
+		// cleared it.
+		req.AddCookie(&http.Cookie{Name: "oidc_state", Value: callback.Query().Get("state")})
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("callback %d: got status %d, want %d", i+1, resp.StatusCode, want)
+		}
+	}
+}
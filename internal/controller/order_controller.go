@@ -1,14 +1,11 @@
 package controller
 
 import (
-	"database/sql"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"petstore/infrastructure"
-	"petstore/internal/model"
+	"petstore/internal/dto"
+	"petstore/internal/middleware"
 	"petstore/internal/service"
 	"strconv"
 
@@ -20,8 +17,12 @@ type OrderController struct {
 	Responder infrastructure.Responder
 }
 
-func RegisterOrderRoutes(r chi.Router, oc *OrderController) {
+// RegisterOrderRoutes keeps ordering open to guests; optionalAuth identifies
+// logged in customers so their orders are linked to their account. API keys
+// need the store scope.
+func RegisterOrderRoutes(r chi.Router, oc *OrderController, optionalAuth func(http.Handler) http.Handler) {
 	r.Route("/store/order", func(r chi.Router) {
+		r.Use(optionalAuth, middleware.RequireScope("store"))
 		r.Post("/", addOrder(oc))
 		r.Route("/{orderId}", func(r chi.Router) {
 			r.Get("/", getOrderByID(oc))
@@ -32,32 +33,32 @@ func RegisterOrderRoutes(r chi.Router, oc *OrderController) {
 
 // CreateOrder godoc
 // @Summary      Place an order for a pet
-// @Description  Places a new order in the system
+// @Description  Places a new order in the system. Orders placed with credentials are linked to the customer's account.
 // @Tags         store
-// @Accept       json
-// @Produce      json
-// @Param        order body model.Order true "order placed for purchasing the pet"
-// @Success      200 {object} model.Order
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
+// @Param        order body dto.OrderRequest true "order placed for purchasing the pet"
+// @Success      201 {object} dto.OrderResponse
+// @Failure      400 {object} dto.Problem "invalid order, with the offending fields"
+// @Failure      403 {object} dto.Problem "api key lacks the store:write scope"
 // @Router       /store/order [post]
 func addOrder(oc *OrderController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var o model.Order
+		var req dto.OrderRequest
 
-		if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
-			oc.Responder.ErrorBadRequest(w, err)
+		if err := decodeBody(r, &req); err != nil {
+			oc.Responder.Error(w, r, err)
 			return
 		}
 
-		order, err := oc.Service.CreateOrder(r.Context(), o)
+		customer := middleware.CetUserFromContext(r.Context())
+		order, err := oc.Service.CreateOrder(r.Context(), req.ToModel(), customer)
 		if err != nil {
-			log.Printf("Error creating order %v: %v", order, err)
-			oc.Responder.ErrorInternal(w, err)
+			oc.Responder.Error(w, r, err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(order)
+		oc.Responder.OutputStatus(w, r, http.StatusCreated, dto.NewOrderResponse(order))
 	}
 }
 
@@ -65,10 +66,12 @@ func addOrder(oc *OrderController) http.HandlerFunc {
 // @Summary      Find purchase order by ID
 // @Description  For valid response try integer IDs with value >= 1 and <= 10. Other values will generate exceptions
 // @Tags         store
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
 // @Param        orderId path int true "ID of pet that needs to be fetched"
-// @Success      200 {object} model.Order
+// @Success      200 {object} dto.OrderResponse
+// @Failure      403 {object} dto.Problem "api key lacks the store:read scope"
+// @Failure      404 {object} dto.Problem "order not found"
 // @Router       /store/order/{orderId} [get]
 func getOrderByID(oc *OrderController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -76,22 +79,17 @@ func getOrderByID(oc *OrderController) http.HandlerFunc {
 
 		orderID, err := strconv.Atoi(orderIDStr)
 		if err != nil {
-			oc.Responder.ErrorBadRequest(w, fmt.Errorf("invalid order ID"))
+			oc.Responder.ErrorBadRequest(w, r, fmt.Errorf("invalid order ID"))
 			return
 		}
 
 		order, err := oc.Service.FindOrderByID(r.Context(), orderID)
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				oc.Responder.ErrorNotFound(w, fmt.Errorf("order not found"))
-				return
-			}
-			log.Printf("Error finding order by ID %d: %v", orderID, err)
-			oc.Responder.ErrorInternal(w, err)
+			oc.Responder.Error(w, r, err)
 			return
 		}
 
-		oc.Responder.OutputJSON(w, order)
+		oc.Responder.Output(w, r, dto.NewOrderResponse(order))
 	}
 }
 
@@ -99,10 +97,13 @@ func getOrderByID(oc *OrderController) http.HandlerFunc {
 // @Summary      Delete purchase order by ID
 // @Description  For valid response try integer IDs with positive integer value. Negative or non-integer values will generate API errors
 // @Tags         store
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
 // @Param        orderId path int true "ID of the order that needs to be deleted"
 // @Success      200 {object} model.ApiResponse "successful operation"
+// @Failure      403 {object} dto.Problem "api key lacks the store:write scope"
+// @Failure      404 {object} dto.Problem "order not found"
+// @Failure      409 {object} dto.Problem "completed orders cannot be deleted"
 // @Router       /store/order/{orderId} [delete]
 func deleteOrder(oc *OrderController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -110,13 +111,12 @@ func deleteOrder(oc *OrderController) http.HandlerFunc {
 
 		orderID, err := strconv.Atoi(orderIDStr)
 		if err != nil {
-			oc.Responder.ErrorBadRequest(w, fmt.Errorf("invalid order ID"))
+			oc.Responder.ErrorBadRequest(w, r, fmt.Errorf("invalid order ID"))
 			return
 		}
 
 		if err := oc.Service.DeleteOrder(r.Context(), orderID); err != nil {
-			log.Printf("Error deleting order ID %d: %v", orderID, err)
-			oc.Responder.ErrorInternal(w, err)
+			oc.Responder.Error(w, r, err)
 			return
 		}
 
@@ -128,20 +128,20 @@ func deleteOrder(oc *OrderController) http.HandlerFunc {
 // @Summary      Returns pet inventories by status
 // @Description  Returns a map of status codes to quantities
 // @Tags         store
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
 // @Success      200 {object} map[string]int "successful operation"
 // @Security     ApiKeyAuth
+// @Security     XAPIKey
 // @Router       /store/inventory [get]
 func GetInventory(oc *OrderController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		inventory, err := oc.Service.GetInventory(r.Context())
 		if err != nil {
-			log.Printf("Error finding inventory")
-			oc.Responder.ErrorInternal(w, err)
+			oc.Responder.Error(w, r, err)
 			return
 		}
 
-		oc.Responder.OutputJSON(w, inventory)
+		oc.Responder.Output(w, r, inventory)
 	}
 }
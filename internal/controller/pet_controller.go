@@ -1,15 +1,13 @@
 package controller
 
 import (
-	"database/sql"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"petstore/infrastructure"
-	"petstore/internal/model"
+	"petstore/internal/apperror"
+	"petstore/internal/dto"
 	"petstore/internal/service"
+	"petstore/internal/validate"
 	"strconv"
 	"strings"
 
@@ -42,36 +40,31 @@ func RegisterPetRoutes(r chi.Router, pc *PetController) {
 
 // @Summary Add a new pet to the store
 // @Tags pet
-// @Accept json
-// @Produce json
-// @Param body body model.Pet true "Pet to add"
-// @Success 200 {object} model.Pet
+// @Accept json,xml,application/yaml
+// @Produce json,xml,application/yaml
+// @Param body body dto.PetRequest true "Pet to add"
+// @Success 201 {object} dto.PetResponse
 // @Security ApiKeyAuth
+// @Security XAPIKey
+// @Failure 400 {object} dto.Problem "invalid pet, with the offending fields"
 // @Router /pet [post]
 func addPet(pc *PetController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var p model.Pet
+		var req dto.PetRequest
 
-		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-			pc.Responder.ErrorBadRequest(w, err)
-			return
-		}
-
-		if err := service.ValidatePet(p); err != nil {
-			pc.Responder.ErrorBadRequest(w, err)
+		if err := decodeBody(r, &req); err != nil {
+			pc.Responder.Error(w, r, err)
 			return
 		}
+		p := req.ToModel()
 
 		pet, err := pc.Service.CreatePet(r.Context(), p)
 		if err != nil {
-			log.Printf("Error creating pet %v: %v", pet, err)
-			pc.Responder.ErrorInternal(w, err)
+			pc.Responder.Error(w, r, err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(pet)
+		pc.Responder.OutputStatus(w, r, http.StatusCreated, dto.NewPetResponse(pet))
 	}
 }
 
@@ -79,34 +72,32 @@ func addPet(pc *PetController) http.HandlerFunc {
 // @Summary      Update an existing pet
 // @Description  Update an existing pet in the store
 // @Tags         pet
-// @Accept       json
-// @Produce      json
-// @Param        body  body  model.Pet  true  "Pet to update"
-// @Success      200  {object}  model.Pet
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
+// @Param        body  body  dto.PetRequest  true  "Pet to update"
+// @Success      200  {object}  dto.PetResponse
 // @Security ApiKeyAuth
+// @Security XAPIKey
+// @Failure      400  {object}  dto.Problem  "invalid pet, with the offending fields"
+// @Failure      404  {object}  dto.Problem  "pet not found"
 // @Router       /pet [put]
 func updatePet(pc *PetController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var p model.Pet
-
-		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-			pc.Responder.ErrorBadRequest(w, err)
-			return
-		}
+		var req dto.PetRequest
 
-		if err := service.ValidatePet(p); err != nil {
-			pc.Responder.ErrorBadRequest(w, err)
+		if err := decodeBody(r, &req); err != nil {
+			pc.Responder.Error(w, r, err)
 			return
 		}
+		p := req.ToModel()
 
 		pet, err := pc.Service.UpdatePet(r.Context(), p)
 		if err != nil {
-			log.Printf("Error updating pet: %v", err)
-			pc.Responder.ErrorInternal(w, err)
+			pc.Responder.Error(w, r, err)
 			return
 		}
 
-		pc.Responder.OutputJSON(w, pet)
+		pc.Responder.Output(w, r, dto.NewPetResponse(pet))
 	}
 }
 
@@ -114,17 +105,18 @@ func updatePet(pc *PetController) http.HandlerFunc {
 // @Summary      Finds Pets by status
 // @Description  Multiple status values can be provided with comma separated strings
 // @Tags         pet
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
 // @Param        status query []string true "Status values that need to be considered for filter" Enums(available, pending, sold)
-// @Success      200 {array} model.Pet "successful operation"
+// @Success      200 {array} dto.PetResponse "successful operation"
 // @Security ApiKeyAuth
+// @Security XAPIKey
 // @Router       /pet/findByStatus [get]
 func getPetsByStatus(pc *PetController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		status := r.URL.Query().Get("status")
 		if status == "" {
-			pc.Responder.ErrorBadRequest(w, fmt.Errorf("status query parameter is required"))
+			pc.Responder.ErrorBadRequest(w, r, fmt.Errorf("status query parameter is required"))
 			return
 		}
 
@@ -132,17 +124,16 @@ func getPetsByStatus(pc *PetController) http.HandlerFunc {
 
 		pets, err := pc.Service.FindPetByStatus(r.Context(), statuses)
 		if err != nil {
-			log.Printf("Error finding pets by status %s: %v", status, err)
-			pc.Responder.ErrorInternal(w, err)
+			pc.Responder.Error(w, r, err)
 			return
 		}
 
 		if len(pets) == 0 {
-			pc.Responder.ErrorNotFound(w, fmt.Errorf("no pets found for given statuses"))
+			pc.Responder.Error(w, r, apperror.NotFound("no pets found for given statuses"))
 			return
 		}
 
-		pc.Responder.OutputJSON(w, pets)
+		pc.Responder.Output(w, r, dto.NewPetResponses(pets))
 	}
 }
 
@@ -150,18 +141,19 @@ func getPetsByStatus(pc *PetController) http.HandlerFunc {
 // @Summary      Finds Pets by tags
 // @Description  Multiple tags can be provided with comma separated strings. Use tag1, tag2, tag3 for testing.
 // @Tags         pet
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
 // @Param        tags query []string true "Tags to filter by"
-// @Success      200 {array} model.Pet "successful operation"
+// @Success      200 {array} dto.PetResponse "successful operation"
 // @Router       /pet/findByTags [get]
 // @Security ApiKeyAuth
+// @Security XAPIKey
 // @Deprecated
 func getPetsByTags(pc *PetController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tagsParam := r.URL.Query().Get("tags")
 		if tagsParam == "" {
-			pc.Responder.ErrorBadRequest(w, fmt.Errorf("tags query parameter is required"))
+			pc.Responder.ErrorBadRequest(w, r, fmt.Errorf("tags query parameter is required"))
 			return
 		}
 
@@ -169,17 +161,16 @@ func getPetsByTags(pc *PetController) http.HandlerFunc {
 
 		pets, err := pc.Service.FindPetByTags(r.Context(), tags)
 		if err != nil {
-			log.Printf("Error finding pets by tags %v: %v", tags, err)
-			pc.Responder.ErrorInternal(w, err)
+			pc.Responder.Error(w, r, err)
 			return
 		}
 
 		if len(pets) == 0 {
-			pc.Responder.ErrorNotFound(w, fmt.Errorf("no pets found for given tags"))
+			pc.Responder.Error(w, r, apperror.NotFound("no pets found for given tags"))
 			return
 		}
 
-		pc.Responder.OutputJSON(w, pets)
+		pc.Responder.Output(w, r, dto.NewPetResponses(pets))
 	}
 }
 
@@ -187,11 +178,12 @@ func getPetsByTags(pc *PetController) http.HandlerFunc {
 // @Summary      Find pet by ID
 // @Description  Returns a single pet
 // @Tags         pet
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
 // @Param        petId path int true "ID of pet to return"
-// @Success      200 {object} model.Pet "successful operation"
+// @Success      200 {object} dto.PetResponse "successful operation"
 // @Security ApiKeyAuth
+// @Security XAPIKey
 // @Router       /pet/{petId} [get]
 func getPetByID(pc *PetController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -199,22 +191,17 @@ func getPetByID(pc *PetController) http.HandlerFunc {
 
 		petID, err := strconv.Atoi(petIDStr)
 		if err != nil {
-			pc.Responder.ErrorBadRequest(w, fmt.Errorf("invalid pet ID"))
+			pc.Responder.ErrorBadRequest(w, r, fmt.Errorf("invalid pet ID"))
 			return
 		}
 
 		pet, err := pc.Service.FindPetByID(r.Context(), petID)
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				pc.Responder.ErrorNotFound(w, fmt.Errorf("pet not found"))
-				return
-			}
-			log.Printf("Error finding pet by ID %d: %v", petID, err)
-			pc.Responder.ErrorInternal(w, err)
+			pc.Responder.Error(w, r, err)
 			return
 		}
 
-		pc.Responder.OutputJSON(w, pet)
+		pc.Responder.Output(w, r, dto.NewPetResponse(pet))
 	}
 }
 
@@ -223,12 +210,14 @@ func getPetByID(pc *PetController) http.HandlerFunc {
 // @Description  Updates name and status of pet
 // @Tags         pet
 // @Accept       multipart/form-data
-// @Produce      json
+// @Produce      json,xml,application/yaml
 // @Param        petId path int true "ID of pet that needs to be updated"
 // @Param        name formData string false "Updated name of the pet"
 // @Param        status formData string false "Updated status of the pet"
-// @Success      200 {object} model.Pet "successful operation"
+// @Success      200 {object} dto.PetResponse "successful operation"
 // @Security ApiKeyAuth
+// @Security XAPIKey
+// @Failure      400 {object} dto.Problem "invalid name or status"
 // @Router       /pet/{petId} [post]
 func updatePetForm(pc *PetController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -236,26 +225,26 @@ func updatePetForm(pc *PetController) http.HandlerFunc {
 
 		petID, err := strconv.Atoi(petIDStr)
 		if err != nil {
-			pc.Responder.ErrorBadRequest(w, fmt.Errorf("invalid pet ID"))
+			pc.Responder.ErrorBadRequest(w, r, fmt.Errorf("invalid pet ID"))
 			return
 		}
 
-		name := r.FormValue("name")
-		status := r.FormValue("status")
-
-		if err := service.ValidatePetFormData(name, status); err != nil {
-			pc.Responder.ErrorBadRequest(w, err)
+		form := dto.PetFormRequest{
+			Name:   r.FormValue("name"),
+			Status: r.FormValue("status"),
+		}
+		if err := validate.Struct(form); err != nil {
+			pc.Responder.Error(w, r, err)
 			return
 		}
 
-		pet, err := pc.Service.UpdatePetFormData(r.Context(), petID, name, status)
+		pet, err := pc.Service.UpdatePetFormData(r.Context(), petID, form.Name, form.Status)
 		if err != nil {
-			log.Printf("Error updating pet form data: %v", err)
-			pc.Responder.ErrorInternal(w, err)
+			pc.Responder.Error(w, r, err)
 			return
 		}
 
-		pc.Responder.OutputJSON(w, pet)
+		pc.Responder.Output(w, r, dto.NewPetResponse(pet))
 	}
 }
 
@@ -263,11 +252,13 @@ func updatePetForm(pc *PetController) http.HandlerFunc {
 // @Summary      Deletes a pet
 // @Description  Deletes a pet by ID
 // @Tags         pet
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
 // @Param        petId path int true "Pet id to delete"
 // @Success      200 {object} model.ApiResponse "successful operation"
 // @Security ApiKeyAuth
+// @Security XAPIKey
+// @Failure      404 {object} dto.Problem "pet not found"
 // @Router       /pet/{petId} [delete]
 func deletePet(pc *PetController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -275,13 +266,12 @@ func deletePet(pc *PetController) http.HandlerFunc {
 
 		id, err := strconv.Atoi(petIDStr)
 		if err != nil {
-			pc.Responder.ErrorBadRequest(w, fmt.Errorf("invalid pet ID"))
+			pc.Responder.ErrorBadRequest(w, r, fmt.Errorf("invalid pet ID"))
 			return
 		}
 
 		if err := pc.Service.DeletePet(r.Context(), id); err != nil {
-			log.Printf("Error deleting pet ID %d: %v", id, err)
-			pc.Responder.ErrorInternal(w, err)
+			pc.Responder.Error(w, r, err)
 			return
 		}
 
@@ -292,12 +282,13 @@ func deletePet(pc *PetController) http.HandlerFunc {
 // @Summary uploads an image
 // @Tags pet
 // @Accept multipart/form-data
-// @Produce json
+// @Produce json,xml,application/yaml
 // @Param petId path int true "ID of pet to update"
 // @Param additionalMetadata formData string false "Additional data to pass to server"
 // @Param file formData file true "File to upload"
 // @Success 200 {object} model.ApiResponse
 // @Security ApiKeyAuth
+// @Security XAPIKey
 // @Router /pet/{petId}/uploadImage [post]
 func uploadPetImage(pc *PetController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -305,18 +296,18 @@ func uploadPetImage(pc *PetController) http.HandlerFunc {
 
 		id, err := strconv.Atoi(petIDStr)
 		if err != nil {
-			pc.Responder.ErrorBadRequest(w, fmt.Errorf("invalid pet ID"))
+			pc.Responder.ErrorBadRequest(w, r, fmt.Errorf("invalid pet ID"))
 			return
 		}
 
 		file, _, err := r.FormFile("file")
 		if err != nil {
-			pc.Responder.ErrorBadRequest(w, fmt.Errorf("file is required"))
+			pc.Responder.ErrorBadRequest(w, r, fmt.Errorf("file is required"))
 			return
 		}
 		defer file.Close()
 
-		pc.Responder.OutputJSON(w, map[string]string{
+		pc.Responder.Output(w, r, map[string]string{
 			"message": fmt.Sprintf("Image uploaded for pet ID %d (stub)", id),
 		})
 	}
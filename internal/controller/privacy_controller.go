@@ -0,0 +1,224 @@
+package controller
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"petstore/infrastructure"
+	"petstore/internal/apperror"
+	"petstore/internal/dto"
+	"petstore/internal/logging"
+	"petstore/internal/middleware"
+	"petstore/internal/model"
+	"petstore/internal/service"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+type PrivacyController struct {
+	Service   service.PrivacyService
+	Responder infrastructure.Responder
+}
+
+func RegisterPrivacyRoutes(r chi.Router, pc *PrivacyController, auth func(http.Handler) http.Handler) {
+	r.Group(func(r chi.Router) {
+		r.Use(auth, middleware.RequireInteractiveLogin)
+		// The export checks the Accept header itself, as its ZIP archive is
+		// not a negotiated format.
+		r.Get("/user/{username}/export", exportUserData(pc))
+		r.With(infrastructure.RequireAcceptable).Post("/user/{username}/erasure", requestErasure(pc))
+
+		r.Route("/admin/erasure-requests", func(r chi.Router) {
+			r.Use(infrastructure.RequireAcceptable, middleware.RequireRole(model.RoleAdmin))
+			r.Get("/", listErasureRequests(pc))
+			r.Post("/{requestId}/approve", approveErasure(pc))
+			r.Post("/{requestId}/reject", rejectErasure(pc))
+		})
+	})
+}
+
+// ExportUserData godoc
+// @Summary      Export user data
+// @Description  Returns everything stored about the user: profile, orders, API keys and erasure requests. Only the user themselves or an admin may export. The default format is a ZIP archive with one JSON file per section.
+// @Tags         privacy
+// @Produce      json,xml,application/yaml
+// @Produce      application/zip
+// @Param        username path string true "The user whose data is exported"
+// @Param        format query string false "zip (default) or json" Enums(zip, json)
+// @Success      200 {object} dto.UserDataExport
+// @Failure      403 {object} dto.Problem "not allowed to export this user"
+// @Failure      404 {object} dto.Problem "user not found"
+// @Security     ApiKeyAuth
+// @Router       /user/{username}/export [get]
+func exportUserData(pc *PrivacyController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := chi.URLParam(r, "username")
+		if !canAccessUser(r, username) {
+			pc.Responder.Error(w, r, apperror.Forbidden("not allowed to export user %s", username))
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format != "" && format != "zip" && format != "json" {
+			pc.Responder.ErrorBadRequest(w, r, fmt.Errorf("format must be zip or json"))
+			return
+		}
+		if format == "json" && !infrastructure.Acceptable(r) {
+			pc.Responder.Error(w, r, infrastructure.ErrNotAcceptable)
+			return
+		}
+
+		data, err := pc.Service.ExportUserData(r.Context(), username)
+		if err != nil {
+			pc.Responder.Error(w, r, err)
+			return
+		}
+		export := dto.NewUserDataExport(data)
+
+		if format == "json" {
+			pc.Responder.Output(w, r, export)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", data.User.Username+"-export.zip"))
+		if err := writeExportZip(w, export); err != nil {
+			logging.FromContext(r.Context()).Error("writing export archive failed", "username", username, "err", err)
+		}
+	}
+}
+
+func writeExportZip(w http.ResponseWriter, export dto.UserDataExport) error {
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"orders.json", export.Orders},
+		{"api_keys.json", export.APIKeys},
+		{"erasure_requests.json", export.ErasureRequests},
+	}
+
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// RequestErasure godoc
+// @Summary      Request account erasure
+// @Description  Files a request to anonymize the user's personal data. An admin has to approve it; orders are kept for accounting but no longer identify the user.
+// @Tags         privacy
+// @Produce      json,xml,application/yaml
+// @Param        username path string true "The user to erase"
+// @Success      201 {object} model.ErasureRequest
+// @Failure      403 {object} dto.Problem "not allowed to erase this user"
+// @Failure      409 {object} dto.Problem "a request is already pending"
+// @Security     ApiKeyAuth
+// @Router       /user/{username}/erasure [post]
+func requestErasure(pc *PrivacyController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := chi.URLParam(r, "username")
+		if !canAccessUser(r, username) {
+			pc.Responder.Error(w, r, apperror.Forbidden("not allowed to erase user %s", username))
+			return
+		}
+
+		req, err := pc.Service.RequestErasure(r.Context(), username)
+		if err != nil {
+			pc.Responder.Error(w, r, err)
+			return
+		}
+
+		pc.Responder.OutputStatus(w, r, http.StatusCreated, req)
+	}
+}
+
+// ListErasureRequests godoc
+// @Summary      List erasure requests
+// @Description  Admin only.
+// @Tags         privacy
+// @Produce      json,xml,application/yaml
+// @Param        status query string false "Filter by status" Enums(pending, approved, rejected)
+// @Success      200 {array} model.ErasureRequest
+// @Security     ApiKeyAuth
+// @Router       /admin/erasure-requests [get]
+func listErasureRequests(pc *PrivacyController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reqs, err := pc.Service.ListErasureRequests(r.Context(), r.URL.Query().Get("status"))
+		if err != nil {
+			pc.Responder.Error(w, r, err)
+			return
+		}
+
+		pc.Responder.Output(w, r, reqs)
+	}
+}
+
+// ApproveErasure godoc
+// @Summary      Approve an erasure request
+// @Description  Admin only. Anonymizes the user's personal fields and removes their credentials, tokens and API keys. Cannot be undone.
+// @Tags         privacy
+// @Produce      json,xml,application/yaml
+// @Param        requestId path int true "ID of the erasure request"
+// @Success      200 {object} model.ErasureRequest
+// @Failure      404 {object} dto.Problem "request not found or already decided"
+// @Security     ApiKeyAuth
+// @Router       /admin/erasure-requests/{requestId}/approve [post]
+func approveErasure(pc *PrivacyController) http.HandlerFunc {
+	return decideErasure(pc, service.PrivacyService.ApproveErasure)
+}
+
+// RejectErasure godoc
+// @Summary      Reject an erasure request
+// @Description  Admin only.
+// @Tags         privacy
+// @Produce      json,xml,application/yaml
+// @Param        requestId path int true "ID of the erasure request"
+// @Success      200 {object} model.ErasureRequest
+// @Failure      404 {object} dto.Problem "request not found or already decided"
+// @Security     ApiKeyAuth
+// @Router       /admin/erasure-requests/{requestId}/reject [post]
+func rejectErasure(pc *PrivacyController) http.HandlerFunc {
+	return decideErasure(pc, service.PrivacyService.RejectErasure)
+}
+
+type erasureDecision func(s service.PrivacyService, ctx context.Context, id int64, admin string) (model.ErasureRequest, error)
+
+func decideErasure(pc *PrivacyController, decide erasureDecision) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "requestId"), 10, 64)
+		if err != nil {
+			pc.Responder.ErrorBadRequest(w, r, fmt.Errorf("invalid request ID"))
+			return
+		}
+
+		admin := middleware.CetUserFromContext(r.Context())
+		req, err := decide(pc.Service, r.Context(), id, admin)
+		if err != nil {
+			pc.Responder.Error(w, r, err)
+			return
+		}
+
+		pc.Responder.Output(w, r, req)
+	}
+}
+
+// canAccessUser reports whether the caller is the given user or an admin.
+func canAccessUser(r *http.Request, username string) bool {
+	p := middleware.PrincipalFromContext(r.Context())
+	return strings.EqualFold(p.Username, username) || p.Role == model.RoleAdmin
+}
@@ -0,0 +1,17 @@
+package controller
+
+import (
+	"net/http"
+	"petstore/infrastructure"
+	"petstore/internal/validate"
+)
+
+// decodeBody reads the body, in any format the API accepts, into v and
+// checks it against the rules in its validate tags, so services only see
+// well-formed input.
+func decodeBody(r *http.Request, v interface{}) error {
+	if err := infrastructure.DecodeBody(r, v); err != nil {
+		return err
+	}
+	return validate.Struct(v)
+}
@@ -1,16 +1,21 @@
 package controller
 
 import (
-	"context"
-	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"math"
+	"net"
 	"net/http"
 	"petstore/infrastructure"
+	"petstore/internal/apperror"
+	"petstore/internal/dto"
+	"petstore/internal/logging"
+	"petstore/internal/middleware"
 	"petstore/internal/model"
 	"petstore/internal/service"
+	"petstore/internal/validate"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
 )
@@ -18,20 +23,40 @@ import (
 type UserController struct {
 	Service   service.UserService
 	Responder infrastructure.Responder
+	// MaxBatchSize caps the users in one bulk creation request.
+	MaxBatchSize int
 }
 
-func RegisterUserRoutes(r chi.Router, uc *UserController) {
+func RegisterUserRoutes(r chi.Router, uc *UserController, auth func(http.Handler) http.Handler) {
 	r.Route("/user", func(r chi.Router) {
 		r.Post("/", addUser(uc))
 		r.Route("/{username}", func(r chi.Router) {
 			r.Get("/", getUserByUsername(uc))
-			r.Put("/", updateUser(uc))
-			r.Delete("/", deleteUser(uc))
+			r.Group(func(r chi.Router) {
+				r.Use(auth, middleware.RequireInteractiveLogin)
+				r.Put("/", updateUser(uc))
+				r.Delete("/", deleteUser(uc))
+			})
+		})
+		r.Group(func(r chi.Router) {
+			// Every user in a batch costs a password hash.
+			r.Use(auth, middleware.RequireInteractiveLogin, middleware.RequireRole(model.RoleAdmin))
+			r.Post("/createWithList", addListUsers(uc))
+			r.Post("/createWithArray", addListUsers(uc))
 		})
-		r.Post("/createWithList", addListUsers(uc))
-		r.Post("/createWithArray", addListUsers(uc))
 		r.Get("/login", uc.Login)
+		r.Post("/login", loginWithBody(uc))
+		r.Post("/login/2fa", loginTwoFactor(uc))
 		r.Get("/logout", logout())
+		r.Post("/password/forgot", forgotPassword(uc))
+		r.Post("/password/reset", resetPassword(uc))
+		r.Get("/verify", verifyEmail(uc))
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth, middleware.RequireInteractiveLogin)
+			r.Post("/2fa/enroll", enrollTOTP(uc))
+			r.Post("/2fa/confirm", confirmTOTP(uc))
+		})
 	})
 }
 
@@ -39,82 +64,135 @@ func RegisterUserRoutes(r chi.Router, uc *UserController) {
 // @Summary      Create user
 // @Description  This can only be done by the logged in user.
 // @Tags         user
-// @Accept       json
-// @Produce      json
-// @Param        body body model.User true "Created user object"
-// @Success      200 {object} model.ApiResponse "successful operation"
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
+// @Param        body body dto.UserRequest true "Created user object"
+// @Success      201 {object} dto.UserResponse "successful operation"
+// @Failure      400 {object} dto.Problem "invalid user or password rejected by the password policy"
+// @Failure      409 {object} dto.Problem "username or email already taken"
 // @Router       /user [post]
 func addUser(uc *UserController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var u model.User
-
-		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
-			log.Printf("Error decoding JSON: %v", err)
-			uc.Responder.ErrorBadRequest(w, err)
-			return
-		}
+		var req dto.UserRequest
 
-		if err := validateUser(r.Context(), uc.Service, u); err != nil {
-			log.Printf("Error validate user: %v", err)
-			uc.Responder.ErrorBadRequest(w, err)
+		if err := decodeBody(r, &req); err != nil {
+			uc.Responder.Error(w, r, err)
 			return
 		}
 
-		user, err := uc.Service.CreateUser(r.Context(), u)
+		user, err := uc.Service.CreateUser(r.Context(), req.ToModel())
 		if err != nil {
-			log.Printf("Error creating user: %v", err)
-			uc.Responder.ErrorInternal(w, err)
+			uc.Responder.Error(w, r, err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(user)
+		uc.Responder.OutputStatus(w, r, http.StatusCreated, dto.NewUserResponse(user))
 
 	}
 }
 
 // CreateUsersWithList godoc
 // @Summary      Creates list of users with given input array
-// @Description  Creates list of users with given input array
+// @Description  Admin only. Validates and stores the users in one transaction. In atomic mode (default) nothing is stored if any user is invalid; in partial mode valid users are stored and the rest reported. Batches larger than the configured maximum are rejected.
 // @Tags         user
-// @Accept       json
-// @Produce      json
-// @Param        body body []model.User true "List of user object"
-// @Success      200 {object} model.ApiResponse "successful operation"
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
+// @Param        mode query string false "atomic (default) or partial" Enums(atomic, partial)
+// @Param        body body []dto.UserRequest true "List of user object"
+// @Success      201 {object} dto.UserBatchReport "all users created"
+// @Success      207 {object} dto.UserBatchReport "some users failed (partial mode)"
+// @Failure      400 {object} dto.UserBatchReport "batch rejected (atomic mode), or a problem if the batch is too large"
+// @Failure      401 {object} dto.Problem "authentication required"
+// @Failure      403 {object} dto.Problem "caller is not an admin"
+// @Security     ApiKeyAuth
 // @Router       /user/createWithList [post]
 // @Router       /user/createWithArray [post]
 func addListUsers(uc *UserController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var u []model.User
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = service.BatchModeAtomic
+		}
+		if mode != service.BatchModeAtomic && mode != service.BatchModePartial {
+			uc.Responder.ErrorBadRequest(w, r, fmt.Errorf("mode must be %q or %q", service.BatchModeAtomic, service.BatchModePartial))
+			return
+		}
 
-		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
-			log.Printf("Error decoding JSON: %v", err)
-			uc.Responder.ErrorBadRequest(w, err)
+		var reqs []dto.UserRequest
+
+		if err := infrastructure.DecodeBody(r, &reqs); err != nil {
+			uc.Responder.Error(w, r, err)
+			return
+		}
+		if uc.MaxBatchSize > 0 && len(reqs) > uc.MaxBatchSize {
+			uc.Responder.Error(w, r, apperror.Validation("a batch holds at most %d users, got %d", uc.MaxBatchSize, len(reqs)))
 			return
 		}
 
-		users, err := uc.Service.CreateUserBatch(r.Context(), u)
-		if err != nil {
-			log.Printf("Error creating users: %v", err)
-			uc.Responder.ErrorInternal(w, err)
+		// Items failing request validation are reported like any other
+		// invalid item instead of rejecting the whole request.
+		items := make([]service.UserBatchItem, len(reqs))
+		for i, req := range reqs {
+			items[i] = service.UserBatchItem{Index: i, User: req.ToModel(), Err: validate.Struct(req)}
+		}
+
+		items, err := uc.Service.CreateUserBatch(r.Context(), items, mode)
+		if err != nil && !errors.Is(err, service.ErrBatchRejected) {
+			uc.Responder.Error(w, r, err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(users)
+		report := newUserBatchReport(mode, items, err != nil)
+		status := http.StatusCreated
+		switch {
+		case err != nil:
+			status = http.StatusBadRequest
+		case report.Failed > 0:
+			status = http.StatusMultiStatus
+		}
+
+		uc.Responder.OutputStatus(w, r, status, report)
 	}
 }
 
+func newUserBatchReport(mode string, items []service.UserBatchItem, rejected bool) dto.UserBatchReport {
+	report := dto.UserBatchReport{
+		Mode:  mode,
+		Items: make([]dto.UserBatchItemResult, 0, len(items)),
+	}
+
+	for _, item := range items {
+		result := dto.UserBatchItemResult{
+			Index:    item.Index,
+			Username: item.User.Username,
+		}
+		switch {
+		case item.Err != nil:
+			result.Status = "failed"
+			result.Error = item.Err.Error()
+			report.Failed++
+		case rejected:
+			result.Status = "skipped"
+		default:
+			user := dto.NewUserResponse(item.User)
+			result.Status = "created"
+			result.User = &user
+			report.Created++
+		}
+		report.Items = append(report.Items, result)
+	}
+
+	return report
+}
+
 // GetUserByUsername godoc
 // @Summary      Get user by user name
 // @Description  The name that needs to be fetched. Use user1 for testing.
 // @Tags         user
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
 // @Param        username path string true "The name that needs to be fetched"
-// @Success      200 {object} model.User "successful operation"
+// @Success      200 {object} dto.UserResponse "successful operation"
 // @Router       /user/{username} [get]
 func getUserByUsername(uc *UserController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -122,67 +200,76 @@ func getUserByUsername(uc *UserController) http.HandlerFunc {
 
 		user, err := uc.Service.FindUserByUsername(r.Context(), username)
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				uc.Responder.ErrorNotFound(w, fmt.Errorf("user not found"))
-				return
-			}
-			log.Printf("Error finding user by username %s: %v", username, err)
-			uc.Responder.ErrorInternal(w, err)
+			uc.Responder.Error(w, r, err)
 			return
 		}
 
-		uc.Responder.OutputJSON(w, user)
+		uc.Responder.Output(w, r, dto.NewUserResponse(user))
 	}
 }
 
 // UpdateUser godoc
 // @Summary      Updated user
-// @Description  This can only be done by the logged in user.
+// @Description  Only the user themselves or an admin may update the user.
 // @Tags         user
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
 // @Param        username path string true "name that need to be updated"
-// @Param        user body model.User true "Updated user object"
-// @Success      200 {object} model.User "successful operation"
+// @Param        user body dto.UserUpdateRequest true "Updated user object. An empty password keeps the current one."
+// @Success      200 {object} dto.UserResponse "successful operation"
+// @Failure      400 {object} dto.Problem "password rejected by the password policy"
+// @Failure      401 {object} dto.Problem "not logged in"
+// @Failure      403 {object} dto.Problem "not allowed to update this user"
+// @Failure      409 {object} dto.Problem "email already taken"
+// @Security     ApiKeyAuth
 // @Router       /user/{username} [put]
 func updateUser(uc *UserController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		username := chi.URLParam(r, "username")
+		if !canAccessUser(r, username) {
+			uc.Responder.Error(w, r, apperror.Forbidden("not allowed to update user %s", username))
+			return
+		}
 
-		var u model.User
-		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
-			log.Printf("Error decoding JSON: %v", err)
-			uc.Responder.ErrorBadRequest(w, err)
+		var req dto.UserUpdateRequest
+		if err := decodeBody(r, &req); err != nil {
+			uc.Responder.Error(w, r, err)
 			return
 		}
 
-		updatedUser, err := uc.Service.UpdateUser(r.Context(), username, u)
+		updatedUser, err := uc.Service.UpdateUser(r.Context(), username, req.ToModel())
 		if err != nil {
-			log.Printf("Error updating user: %v", err)
-			uc.Responder.ErrorInternal(w, err)
+			uc.Responder.Error(w, r, err)
 			return
 		}
 
-		uc.Responder.OutputJSON(w, updatedUser)
+		uc.Responder.Output(w, r, dto.NewUserResponse(updatedUser))
 	}
 }
 
 // DeleteUser godoc
 // @Summary      Delete user
-// @Description  This can only be done by the logged in user.
+// @Description  Only the user themselves or an admin may delete the user.
 // @Tags         user
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
 // @Param        username path string true "The name that needs to be deleted"
-// @Success      200 {object} model.ApiResponse "successful operation"
+// @Success      204 "user deleted"
+// @Failure      401 {object} dto.Problem "not logged in"
+// @Failure      403 {object} dto.Problem "not allowed to delete this user"
+// @Failure      404 {object} dto.Problem "user not found"
+// @Security     ApiKeyAuth
 // @Router       /user/{username} [delete]
 func deleteUser(uc *UserController) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		username := chi.URLParam(r, "username")
+		if !canAccessUser(r, username) {
+			uc.Responder.Error(w, r, apperror.Forbidden("not allowed to delete user %s", username))
+			return
+		}
 
 		if err := uc.Service.DeleteUser(r.Context(), username); err != nil {
-			log.Printf("Error deleting user by username %s: %v", username, err)
-			uc.Responder.ErrorInternal(w, err)
+			uc.Responder.Error(w, r, err)
 			return
 		}
 
@@ -192,42 +279,168 @@ func deleteUser(uc *UserController) http.HandlerFunc {
 
 // LoginUser godoc
 // @Summary      Logs user into the system
-// @Description  Logs user into the system
+// @Description  Logs user into the system. Deprecated: the password ends up in access logs, use POST /user/login instead.
 // @Tags         user
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
 // @Param        username query string true "The user name for login"
 // @Param        password query string true "The password for login in clear text"
-// @Success      200 {string} string "token"
+// @Success      200 {object} model.LoginResult "token, or a challenge token if two-factor authentication is enabled"
+// @Failure      403 {object} dto.Problem "account is suspended"
+// @Failure      429 {object} dto.Problem "too many failed attempts"
 // @Router       /user/login [get]
+// @Deprecated
 func (uc *UserController) Login(w http.ResponseWriter, r *http.Request) {
 	username := r.URL.Query().Get("username")
 	password := r.URL.Query().Get("password")
 
+	uc.login(w, r, username, password)
+}
+
+// LoginUserWithBody godoc
+// @Summary      Logs user into the system
+// @Description  Accepts credentials as a JSON or form-encoded body. Repeated failures are throttled per username and per client IP.
+// @Tags         user
+// @Accept       json,xml,application/yaml,x-www-form-urlencoded
+// @Produce      json,xml,application/yaml
+// @Param        body body model.LoginRequest true "Login credentials"
+// @Success      200 {object} model.LoginResult "token, or a challenge token if two-factor authentication is enabled"
+// @Failure      403 {object} dto.Problem "account is suspended"
+// @Failure      429 {object} dto.Problem "too many failed attempts"
+// @Router       /user/login [post]
+func loginWithBody(uc *UserController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.LoginRequest
+
+		contentType := r.Header.Get("Content-Type")
+		if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") ||
+			strings.HasPrefix(contentType, "multipart/form-data") {
+			req.Username = r.PostFormValue("username")
+			req.Password = r.PostFormValue("password")
+		} else if err := decodeBody(r, &req); err != nil {
+			uc.Responder.Error(w, r, err)
+			return
+		}
+
+		uc.login(w, r, req.Username, req.Password)
+	}
+}
+
+func (uc *UserController) login(w http.ResponseWriter, r *http.Request, username, password string) {
 	if username == "" || password == "" {
-		uc.Responder.ErrorBadRequest(w, fmt.Errorf("missing username or password"))
+		uc.Responder.ErrorBadRequest(w, r, fmt.Errorf("missing username or password"))
 		return
 	}
 
-	token, err := uc.Service.Login(r.Context(), username, password)
+	result, err := uc.Service.Login(r.Context(), username, password, clientIP(r))
 	if err != nil {
-		if err.Error() == "invalid credentials" {
-			uc.Responder.ErrorUnauthorized(w, err)
+		uc.loginError(w, r, err)
+		return
+	}
+
+	uc.Responder.Output(w, r, result)
+}
+
+func (uc *UserController) loginError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooMany *service.TooManyAttemptsError
+	if errors.As(err, &tooMany) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(tooMany.RetryAfter.Seconds()))))
+	}
+	uc.Responder.Error(w, r, err)
+}
+
+// LoginTwoFactor godoc
+// @Summary      Complete a two-factor login
+// @Description  Exchanges the challenge token returned by /user/login and a TOTP or recovery code for an access token.
+// @Tags         user
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
+// @Param        body body model.TwoFactorLoginRequest true "Challenge token and code"
+// @Success      200 {object} model.LoginResult "token"
+// @Failure      403 {object} dto.Problem "account is suspended"
+// @Failure      429 {object} dto.Problem "too many failed attempts"
+// @Router       /user/login/2fa [post]
+func loginTwoFactor(uc *UserController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.TwoFactorLoginRequest
+
+		if err := decodeBody(r, &req); err != nil {
+			uc.Responder.Error(w, r, err)
 			return
 		}
-		uc.Responder.ErrorInternal(w, err)
-		return
+		if req.ChallengeToken == "" || req.Code == "" {
+			uc.Responder.ErrorBadRequest(w, r, fmt.Errorf("challenge token and code are required"))
+			return
+		}
+
+		token, err := uc.Service.CompleteTwoFactorLogin(r.Context(), req.ChallengeToken, req.Code, clientIP(r))
+		if err != nil {
+			uc.loginError(w, r, err)
+			return
+		}
+
+		uc.Responder.Output(w, r, model.LoginResult{Token: token})
 	}
+}
+
+// EnrollTOTP godoc
+// @Summary      Start two-factor enrollment
+// @Description  Generates a TOTP secret for the logged in user. Two-factor authentication is enabled only after /user/2fa/confirm.
+// @Tags         user
+// @Produce      json,xml,application/yaml
+// @Success      200 {object} model.TOTPEnrollment "secret and otpauth URI"
+// @Security     ApiKeyAuth
+// @Router       /user/2fa/enroll [post]
+func enrollTOTP(uc *UserController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := middleware.CetUserFromContext(r.Context())
+
+		enrollment, err := uc.Service.EnrollTOTP(r.Context(), username)
+		if err != nil {
+			uc.Responder.Error(w, r, err)
+			return
+		}
 
-	uc.Responder.OutputJSON(w, map[string]string{"token": token})
+		uc.Responder.Output(w, r, enrollment)
+	}
+}
+
+// ConfirmTOTP godoc
+// @Summary      Confirm two-factor enrollment
+// @Description  Verifies the first code from the authenticator, enables two-factor authentication and returns one-time recovery codes.
+// @Tags         user
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
+// @Param        body body model.TOTPConfirmRequest true "Code from the authenticator app"
+// @Success      200 {object} model.RecoveryCodes "recovery codes, shown only once"
+// @Security     ApiKeyAuth
+// @Router       /user/2fa/confirm [post]
+func confirmTOTP(uc *UserController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := middleware.CetUserFromContext(r.Context())
+
+		var req model.TOTPConfirmRequest
+		if err := decodeBody(r, &req); err != nil {
+			uc.Responder.Error(w, r, err)
+			return
+		}
+
+		codes, err := uc.Service.ConfirmTOTP(r.Context(), username, req.Code)
+		if err != nil {
+			uc.Responder.Error(w, r, err)
+			return
+		}
+
+		uc.Responder.Output(w, r, model.RecoveryCodes{RecoveryCodes: codes})
+	}
 }
 
 // LogoutUser godoc
 // @Summary      Logs out current logged in user session
 // @Description  Logs out current logged in user session
 // @Tags         user
-// @Accept       json
-// @Produce      json
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
 // @Success 200 {string} string "ok"
 // @Router       /user/logout [get]
 func logout() http.HandlerFunc {
@@ -236,19 +449,111 @@ func logout() http.HandlerFunc {
 	}
 }
 
-func validateUser(ctx context.Context, service service.UserService, user model.User) error {
-	if user.Username == "" {
-		return errors.New("username is required")
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  Sends a single-use reset token to the email address if it belongs to an account. The response is the same whether or not it does.
+// @Tags         user
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
+// @Param        body body model.ForgotPasswordRequest true "Account email"
+// @Success      202 {object} model.ApiResponse "request accepted"
+// @Router       /user/password/forgot [post]
+func forgotPassword(uc *UserController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.ForgotPasswordRequest
+
+		if err := decodeBody(r, &req); err != nil {
+			uc.Responder.Error(w, r, err)
+			return
+		}
+		if req.Email == "" {
+			uc.Responder.ErrorBadRequest(w, r, fmt.Errorf("email is required"))
+			return
+		}
+
+		if err := uc.Service.RequestPasswordReset(r.Context(), req.Email); err != nil {
+			logging.FromContext(r.Context()).Error("requesting password reset failed", "err", err)
+		}
+
+		uc.Responder.OutputStatus(w, r, http.StatusAccepted, model.ApiResponse{
+			Code:    http.StatusAccepted,
+			Type:    "success",
+			Message: "if the email is registered, a reset link has been sent",
+		})
 	}
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Sets a new password using a token from the reset email. Tokens are single-use and expire after an hour.
+// @Tags         user
+// @Accept       json,xml,application/yaml
+// @Produce      json,xml,application/yaml
+// @Param        body body model.ResetPasswordRequest true "Reset token and new password"
+// @Success      200 {object} model.ApiResponse "successful operation"
+// @Failure      400 {object} dto.Problem "invalid token or password rejected by the password policy"
+// @Router       /user/password/reset [post]
+func resetPassword(uc *UserController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.ResetPasswordRequest
+
+		if err := decodeBody(r, &req); err != nil {
+			uc.Responder.Error(w, r, err)
+			return
+		}
+		if req.Token == "" || req.Password == "" {
+			uc.Responder.ErrorBadRequest(w, r, fmt.Errorf("token and password are required"))
+			return
+		}
+
+		if err := uc.Service.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+			uc.Responder.Error(w, r, err)
+			return
+		}
 
-	_, err := service.FindUserByUsername(ctx, user.Username)
-	if err == nil {
-		return errors.New("username already exist")
+		uc.Responder.Output(w, r, model.ApiResponse{
+			Code:    http.StatusOK,
+			Type:    "success",
+			Message: "password has been reset",
+		})
 	}
+}
+
+// VerifyEmail godoc
+// @Summary      Verify email address
+// @Description  Confirms the email address using the token from the verification email and activates the account.
+// @Tags         user
+// @Produce      json,xml,application/yaml
+// @Param        token query string true "Verification token"
+// @Success      200 {object} model.ApiResponse "successful operation"
+// @Failure      400 {object} dto.Problem "invalid or expired token"
+// @Failure      409 {object} dto.Problem "account is not awaiting email verification"
+// @Router       /user/verify [get]
+func verifyEmail(uc *UserController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			uc.Responder.ErrorBadRequest(w, r, fmt.Errorf("token query parameter is required"))
+			return
+		}
 
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil
+		if err := uc.Service.VerifyEmail(r.Context(), token); err != nil {
+			uc.Responder.Error(w, r, err)
+			return
+		}
+
+		uc.Responder.Output(w, r, model.ApiResponse{
+			Code:    http.StatusOK,
+			Type:    "success",
+			Message: "email verified",
+		})
 	}
+}
 
-	return fmt.Errorf("error checking username: %w", err)
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
@@ -1,73 +1,67 @@
 package db
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"math/rand/v2"
+	"petstore/internal/config"
 	"time"
 
-	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
-	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
-func InitDBAndMigrate() (*sqlx.DB, error) {
-	err := godotenv.Load()
+// Connect opens the connection pool and waits until the database accepts
+// connections. It doesn't touch the schema, see Migrator.
+func Connect(ctx context.Context, cfg config.DatabaseConfig) (*sqlx.DB, error) {
+	dbConn, err := sqlx.Open("postgres", cfg.DSN())
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		return nil, fmt.Errorf("error opening db: %w", err)
 	}
+	dbConn.SetMaxOpenConns(cfg.Pool.MaxOpenConns)
+	dbConn.SetMaxIdleConns(cfg.Pool.MaxIdleConns)
+	dbConn.SetConnMaxLifetime(cfg.Pool.ConnMaxLifetime)
+	dbConn.SetConnMaxIdleTime(cfg.Pool.ConnMaxIdleTime)
 
-	dbUser := os.Getenv("DB_USER")
-	dbPassword := os.Getenv("DB_PASSWORD")
-	dbHost := os.Getenv("DB_HOST")
-	dbPort := os.Getenv("DB_PORT")
-	dbName := os.Getenv("DB_NAME")
-
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		dbUser, dbPassword, dbHost, dbPort, dbName)
-
-	dbConn, err := connectWhithRetry(dsn, 5, 2*time.Second)
-	if err != nil {
-		return nil, fmt.Errorf("error connecting db: %w", err)
-	}
-
-	if err := runMigrations(dsn); err != nil {
+	if err := pingWithRetry(ctx, dbConn, cfg.Retry); err != nil {
 		_ = dbConn.Close()
-		return nil, fmt.Errorf("error applying migrations: %w", err)
+		return nil, fmt.Errorf("error connecting db: %w", err)
 	}
-
-	log.Println("Migrations successfully applied!")
 	return dbConn, nil
 }
 
-func runMigrations(databaseURL string) error {
-	m, err := migrate.New("file://migrations", databaseURL)
-	if err != nil {
-		return fmt.Errorf("error initializing migrations: %w", err)
-	}
-
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("error applying migrations: %w", err)
-	}
-
-	return nil
-}
-
-func connectWhithRetry(dsn string, maxAttempts int, delay time.Duration) (*sqlx.DB, error) {
-	var db *sqlx.DB
+func pingWithRetry(ctx context.Context, db *sqlx.DB, retry config.DatabaseRetryConfig) error {
 	var err error
+	for attempt := 1; ; attempt++ {
+		if err = db.PingContext(ctx); err == nil {
+			return nil
+		}
+		if attempt >= retry.Attempts {
+			return fmt.Errorf("cannot connect to db after %d attempts: %w", attempt, err)
+		}
+
+		delay := backoff(retry, attempt)
+		slog.Warn("db connection attempt failed",
+			"attempt", attempt, "max_attempts", retry.Attempts, "retry_in", delay.String(), "err", err)
 
-	for i := 1; i <= maxAttempts; i++ {
-		db, err = sqlx.Connect("postgres", dsn)
-		if err == nil {
-			return db, nil
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up connecting to db: %w", ctx.Err())
+		case <-time.After(delay):
 		}
-		log.Printf("[DB] Attempt %d/%d failed to connect: %v", i, maxAttempts, err)
+	}
+}
 
-		time.Sleep(delay)
+// backoff doubles the delay with every attempt up to the maximum, then picks
+// a random delay between half and all of it, so that replicas restarting
+// together don't retry in lockstep.
+func backoff(retry config.DatabaseRetryConfig, attempt int) time.Duration {
+	d := retry.InitialBackoff
+	for i := 1; i < attempt && d < retry.MaxBackoff; i++ {
+		d *= 2
 	}
-	return nil, fmt.Errorf("cannot connect to db after %d attempts: %w", maxAttempts, err)
+	d = min(d, retry.MaxBackoff)
+	return d/2 + rand.N(d/2+1)
 }
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"petstore/migrations"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// pqUndefinedTable is the Postgres error code for a missing table.
+const pqUndefinedTable = "42P01"
+
+// MigrationCheck reports whether the database schema is at the newest
+// migration shipped with the binary, without a half-applied (dirty) one.
+type MigrationCheck struct {
+	db     *sqlx.DB
+	latest uint
+}
+
+func NewMigrationCheck(conn *sqlx.DB) (*MigrationCheck, error) {
+	latest, err := latestMigration()
+	if err != nil {
+		return nil, err
+	}
+	return &MigrationCheck{db: conn, latest: latest}, nil
+}
+
+// Check reads the applied version from the golang-migrate table directly.
+// Going through the migrate driver would take its advisory lock and create
+// the table if missing, which a probe must not do.
+func (c *MigrationCheck) Check(ctx context.Context) (string, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := c.db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	var pqErr *pq.Error
+	if errors.Is(err, sql.ErrNoRows) || errors.As(err, &pqErr) && pqErr.Code == pqUndefinedTable {
+		return "", fmt.Errorf("no migrations applied, expected version %d", c.latest)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case dirty:
+		return "", fmt.Errorf("migration %d failed and left the schema dirty", version)
+	case uint(version) != c.latest:
+		return "", fmt.Errorf("schema at version %d, expected %d", version, c.latest)
+	}
+	return fmt.Sprintf("version %d", version), nil
+}
+
+func latestMigration() (uint, error) {
+	src, err := openMigrations()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("error reading migrations: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error reading migrations: %w", err)
+		}
+		version = next
+	}
+}
+
+// openMigrations opens the migrations embedded in the binary.
+func openMigrations() (source.Driver, error) {
+	if err := checkMigrationPairs(migrations.FS); err != nil {
+		return nil, err
+	}
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error opening migrations: %w", err)
+	}
+	return src, nil
+}
+
+// checkMigrationPairs makes sure every up migration has a down migration of
+// the same name and the other way round, so a rollback can't stop halfway.
+func checkMigrationPairs(fsys fs.FS) error {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return fmt.Errorf("error listing migrations: %w", err)
+	}
+
+	files := make(map[string]bool, len(names))
+	for _, name := range names {
+		files[name] = true
+	}
+
+	var errs []error
+	for _, name := range names {
+		m, err := source.Parse(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("migration %s is not named like 000001_title.up.sql", name))
+			continue
+		}
+		var pair string
+		switch m.Direction {
+		case source.Up:
+			pair = strings.TrimSuffix(name, ".up.sql") + ".down.sql"
+		case source.Down:
+			pair = strings.TrimSuffix(name, ".down.sql") + ".up.sql"
+		}
+		if !files[pair] {
+			errs = append(errs, fmt.Errorf("migration %s has no matching %s", name, pair))
+		}
+	}
+	return errors.Join(errs...)
+}
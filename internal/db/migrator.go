@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/jmoiron/sqlx"
+)
+
+// MigrationStatus is the schema version of the database next to the newest
+// migration shipped with the binary. Version is 0 when nothing was applied.
+type MigrationStatus struct {
+	Version uint
+	Dirty   bool
+	Latest  uint
+}
+
+func (s MigrationStatus) String() string {
+	switch {
+	case s.Dirty:
+		return fmt.Sprintf("version %d (dirty), latest %d", s.Version, s.Latest)
+	case s.Version == s.Latest:
+		return fmt.Sprintf("version %d, up to date", s.Version)
+	default:
+		return fmt.Sprintf("version %d, latest %d", s.Version, s.Latest)
+	}
+}
+
+// Migrator applies the schema migrations over a connection borrowed from the
+// pool, so they can run as a deploy step separate from the server.
+type Migrator struct {
+	m    *migrate.Migrate
+	conn *sql.Conn
+}
+
+func NewMigrator(ctx context.Context, conn *sqlx.DB) (*Migrator, error) {
+	src, err := openMigrations()
+	if err != nil {
+		return nil, err
+	}
+	c, err := conn.Conn(ctx)
+	if err != nil {
+		_ = src.Close()
+		return nil, err
+	}
+	driver, err := postgres.WithConnection(ctx, c, &postgres.Config{})
+	if err != nil {
+		_ = src.Close()
+		_ = c.Close()
+		return nil, fmt.Errorf("error initializing migrations: %w", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		_ = src.Close()
+		_ = driver.Close()
+		return nil, fmt.Errorf("error initializing migrations: %w", err)
+	}
+	m.Log = migrateLogger{}
+
+	// Migrations may legitimately run longer than the statement timeout
+	// configured for the application. Close resets it.
+	mg := &Migrator{m: m, conn: c}
+	if _, err := c.ExecContext(ctx, `SET statement_timeout = 0`); err != nil {
+		_ = mg.Close()
+		return nil, fmt.Errorf("error disabling statement timeout: %w", err)
+	}
+	return mg, nil
+}
+
+// Up applies every pending migration.
+func (mg *Migrator) Up(ctx context.Context) error {
+	return mg.locked(ctx, func() error {
+		if err := mg.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("error applying migrations: %w", err)
+		}
+		return nil
+	})
+}
+
+// Down rolls back the given number of migrations.
+func (mg *Migrator) Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1")
+	}
+	return mg.locked(ctx, func() error {
+		if err := mg.m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("error rolling back migrations: %w", err)
+		}
+		return nil
+	})
+}
+
+// To migrates up or down to the given version.
+func (mg *Migrator) To(ctx context.Context, version uint) error {
+	return mg.locked(ctx, func() error {
+		if err := mg.m.Migrate(version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("error migrating to version %d: %w", version, err)
+		}
+		return nil
+	})
+}
+
+// Force sets the version without running migrations and clears the dirty
+// flag, after a failed migration was repaired by hand. -1 means no version.
+func (mg *Migrator) Force(ctx context.Context, version int) error {
+	return mg.locked(ctx, func() error {
+		if err := mg.m.Force(version); err != nil {
+			return fmt.Errorf("error forcing version %d: %w", version, err)
+		}
+		return nil
+	})
+}
+
+// migrationLockKey is the advisory lock key of migration runs. golang-migrate
+// takes a lock of its own, but only around each call and without a way to
+// give up waiting.
+const migrationLockKey int64 = 0x70657473746f7265 // "petstore"
+
+const migrationLockPoll = time.Second
+
+// locked runs fn holding the migration lock, so that deploy steps started at
+// the same time, e.g. one per replica, migrate one after another instead of
+// racing. Waiting ends when ctx is done.
+func (mg *Migrator) locked(ctx context.Context, fn func() error) error {
+	waiting := false
+	for {
+		var acquired bool
+		if err := mg.conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, migrationLockKey).Scan(&acquired); err != nil {
+			return fmt.Errorf("error acquiring migration lock: %w", err)
+		}
+		if acquired {
+			break
+		}
+		if !waiting {
+			slog.Info("waiting for another migration run to finish")
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("error acquiring migration lock: %w", ctx.Err())
+		case <-time.After(migrationLockPoll):
+		}
+	}
+	// The connection goes back to the pool on Close, so the session lock has
+	// to be released explicitly.
+	defer func() {
+		if _, err := mg.conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			slog.Warn("releasing migration lock failed", "err", err)
+		}
+	}()
+
+	return fn()
+}
+
+func (mg *Migrator) Status() (MigrationStatus, error) {
+	latest, err := latestMigration()
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+	version, dirty, err := mg.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return MigrationStatus{}, fmt.Errorf("error reading schema version: %w", err)
+	}
+	return MigrationStatus{Version: version, Dirty: dirty, Latest: latest}, nil
+}
+
+// Close returns the borrowed connection, the pool stays open.
+func (mg *Migrator) Close() error {
+	_, resetErr := mg.conn.ExecContext(context.Background(), `RESET statement_timeout`)
+	srcErr, dbErr := mg.m.Close()
+	return errors.Join(resetErr, srcErr, dbErr)
+}
+
+// migrateLogger routes golang-migrate's progress lines through slog.
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...interface{}) {
+	slog.Info(strings.TrimSpace(fmt.Sprintf(format, v...)), "component", "migrate")
+}
+
+func (migrateLogger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"petstore/internal/model"
+	"time"
+)
+
+// AdminUserResponse is the user as shown to admins, with account details
+// that are not part of the public representation.
+type AdminUserResponse struct {
+	UserResponse
+	AuthProvider string     `json:"authProvider,omitempty" xml:"authProvider,omitempty" example:"https://idp.example.com"`
+	LastLoginAt  *time.Time `json:"lastLoginAt" xml:"lastLoginAt"`
+}
+
+type UserPage struct {
+	Items  []AdminUserResponse `json:"items" xml:"items>User"`
+	Total  int                 `json:"total" xml:"total" example:"42"`
+	Limit  int                 `json:"limit" xml:"limit" example:"20"`
+	Offset int                 `json:"offset" xml:"offset" example:"0"`
+}
+
+func NewAdminUserResponse(u model.User) AdminUserResponse {
+	return AdminUserResponse{
+		UserResponse: NewUserResponse(u),
+		AuthProvider: u.AuthProvider,
+		LastLoginAt:  u.LastLoginAt,
+	}
+}
+
+func NewUserPage(users []model.User, total, limit, offset int) UserPage {
+	items := make([]AdminUserResponse, 0, len(users))
+	for _, u := range users {
+		items = append(items, NewAdminUserResponse(u))
+	}
+	return UserPage{Items: items, Total: total, Limit: limit, Offset: offset}
+}
@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"encoding/xml"
+	"petstore/internal/health"
+)
+
+const (
+	HealthStatusUp           = "up"
+	HealthStatusDown         = "down"
+	HealthStatusShuttingDown = "shutting_down"
+)
+
+type HealthResponse struct {
+	XMLName    xml.Name          `json:"-" xml:"health"`
+	Status     string            `json:"status" xml:"status" example:"up"`
+	Components []ComponentHealth `json:"components,omitempty" xml:"components>component,omitempty"`
+}
+
+type ComponentHealth struct {
+	Name   string `json:"name" xml:"name" example:"database"`
+	Status string `json:"status" xml:"status" example:"up"`
+	Detail string `json:"detail,omitempty" xml:"detail,omitempty" example:"version 10"`
+	Error  string `json:"error,omitempty" xml:"error,omitempty"`
+}
+
+// NewReadinessResponse is up only when every component is up.
+func NewReadinessResponse(results []health.Result) HealthResponse {
+	resp := HealthResponse{Status: HealthStatusUp, Components: make([]ComponentHealth, 0, len(results))}
+	for _, res := range results {
+		component := ComponentHealth{Name: res.Name, Status: HealthStatusUp, Detail: res.Detail}
+		if res.Err != nil {
+			component.Status = HealthStatusDown
+			component.Error = res.Err.Error()
+			resp.Status = HealthStatusDown
+		}
+		resp.Components = append(resp.Components, component)
+	}
+	return resp
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"encoding/xml"
+	"petstore/internal/model"
+	"time"
+)
+
+type OrderRequest struct {
+	XMLName  xml.Name  `json:"-" xml:"Order"`
+	PetID    int       `json:"petId" xml:"petId" example:"3" validate:"required,min=1"`
+	Quantity int       `json:"quantity" xml:"quantity" example:"2" validate:"required,min=1,max=1000"`
+	ShipDate time.Time `json:"shipDate" xml:"shipDate" example:"2030-03-29T15:04:05Z" validate:"required,future"`
+	Status   string    `json:"status" xml:"status" example:"placed" validate:"oneof=placed approved delivered"`
+	Complete bool      `json:"complete" xml:"complete" example:"false"`
+}
+
+type OrderResponse struct {
+	XMLName  xml.Name  `json:"-" xml:"Order"`
+	ID       int       `json:"id" xml:"id" example:"10"`
+	PetID    int       `json:"petId" xml:"petId" example:"3"`
+	Quantity int       `json:"quantity" xml:"quantity" example:"2"`
+	ShipDate time.Time `json:"shipDate" xml:"shipDate" example:"2025-03-29T15:04:05Z"`
+	Status   string    `json:"status" xml:"status" example:"placed"`
+	Complete bool      `json:"complete" xml:"complete" example:"false"`
+}
+
+func (r OrderRequest) ToModel() model.Order {
+	return model.Order{
+		PetID:    r.PetID,
+		Quantity: r.Quantity,
+		ShipDate: r.ShipDate,
+		Status:   r.Status,
+		Complete: r.Complete,
+	}
+}
+
+func NewOrderResponse(o model.Order) OrderResponse {
+	return OrderResponse{
+		ID:       o.ID,
+		PetID:    o.PetID,
+		Quantity: o.Quantity,
+		ShipDate: o.ShipDate,
+		Status:   o.Status,
+		Complete: o.Complete,
+	}
+}
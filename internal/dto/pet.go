@@ -0,0 +1,88 @@
+package dto
+
+import (
+	"encoding/xml"
+	"petstore/internal/model"
+)
+
+type Category struct {
+	ID   int    `json:"id" xml:"id" example:"2" validate:"min=0"`
+	Name string `json:"name" xml:"name" example:"Dog" validate:"max=255"`
+}
+
+type Tag struct {
+	ID   int    `json:"id" xml:"id" example:"1" validate:"min=0"`
+	Name string `json:"name" xml:"name" example:"cute" validate:"required,max=64"`
+}
+
+// PetRequest is the body of POST and PUT /pet. ID is ignored on create and
+// identifies the pet on update.
+type PetRequest struct {
+	XMLName   xml.Name `json:"-" xml:"Pet"`
+	ID        int      `json:"id" xml:"id" example:"1" validate:"min=0"`
+	Category  Category `json:"category" xml:"category"`
+	Name      string   `json:"name" xml:"name" example:"Rex" validate:"required,max=255"`
+	PhotoUrls []string `json:"photoUrls" xml:"photoUrls>photoUrl" example:"https://example.com/photo.jpg" validate:"max=20,dive,url"`
+	Tags      []Tag    `json:"tags" xml:"tags>tag" validate:"max=20"`
+	Status    string   `json:"status" xml:"status" example:"available" validate:"required,oneof=available pending sold"`
+}
+
+// PetFormRequest holds the form fields of POST /pet/{petId}.
+type PetFormRequest struct {
+	Name   string `json:"name" validate:"required,max=255"`
+	Status string `json:"status" validate:"required,oneof=available pending sold"`
+}
+
+type PetResponse struct {
+	XMLName   xml.Name `json:"-" xml:"Pet"`
+	ID        int      `json:"id" xml:"id" example:"1"`
+	Category  Category `json:"category" xml:"category"`
+	Name      string   `json:"name" xml:"name" example:"Rex"`
+	PhotoUrls []string `json:"photoUrls" xml:"photoUrls>photoUrl" example:"https://example.com/photo.jpg"`
+	Tags      []Tag    `json:"tags" xml:"tags>tag"`
+	Status    string   `json:"status" xml:"status" example:"available"`
+}
+
+func (r PetRequest) ToModel() model.Pet {
+	tags := make([]model.Tag, 0, len(r.Tags))
+	for _, t := range r.Tags {
+		tags = append(tags, model.Tag{ID: t.ID, Name: t.Name})
+	}
+
+	return model.Pet{
+		ID:        r.ID,
+		Category:  model.Category{ID: r.Category.ID, Name: r.Category.Name},
+		Name:      r.Name,
+		PhotoUrls: r.PhotoUrls,
+		Tags:      tags,
+		Status:    r.Status,
+	}
+}
+
+func NewPetResponse(p model.Pet) PetResponse {
+	tags := make([]Tag, 0, len(p.Tags))
+	for _, t := range p.Tags {
+		tags = append(tags, Tag{ID: t.ID, Name: t.Name})
+	}
+	photoUrls := p.PhotoUrls
+	if photoUrls == nil {
+		photoUrls = []string{}
+	}
+
+	return PetResponse{
+		ID:        p.ID,
+		Category:  Category{ID: p.Category.ID, Name: p.Category.Name},
+		Name:      p.Name,
+		PhotoUrls: photoUrls,
+		Tags:      tags,
+		Status:    p.Status,
+	}
+}
+
+func NewPetResponses(pets []model.Pet) []PetResponse {
+	res := make([]PetResponse, 0, len(pets))
+	for _, p := range pets {
+		res = append(res, NewPetResponse(p))
+	}
+	return res
+}
@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"petstore/internal/model"
+	"time"
+)
+
+// UserDataExport is the bundle handed out for a data subject access request.
+// It holds the same user representation as every other endpoint, so
+// credentials and secrets are never part of it.
+type UserDataExport struct {
+	ExportedAt      time.Time              `json:"exportedAt" xml:"exportedAt"`
+	Profile         UserResponse           `json:"profile" xml:"User"`
+	Orders          []OrderResponse        `json:"orders" xml:"orders>Order"`
+	APIKeys         []model.APIKey         `json:"apiKeys" xml:"apiKeys>apiKey"`
+	ErasureRequests []model.ErasureRequest `json:"erasureRequests" xml:"erasureRequests>erasureRequest"`
+}
+
+func NewUserDataExport(e model.UserDataExport) UserDataExport {
+	orders := make([]OrderResponse, 0, len(e.Orders))
+	for _, o := range e.Orders {
+		orders = append(orders, NewOrderResponse(o))
+	}
+
+	keys := e.APIKeys
+	if keys == nil {
+		keys = []model.APIKey{}
+	}
+	erasures := e.ErasureRequests
+	if erasures == nil {
+		erasures = []model.ErasureRequest{}
+	}
+
+	return UserDataExport{
+		ExportedAt:      e.ExportedAt,
+		Profile:         NewUserResponse(e.User),
+		Orders:          orders,
+		APIKeys:         keys,
+		ErasureRequests: erasures,
+	}
+}
@@ -0,0 +1,19 @@
+package dto
+
+import (
+	"encoding/xml"
+	"petstore/internal/apperror"
+)
+
+// Problem is an RFC 7807 error response, served as application/problem+json
+// or application/problem+xml.
+type Problem struct {
+	XMLName   xml.Name              `json:"-" xml:"urn:ietf:rfc:7807 problem"`
+	Type      string                `json:"type" xml:"type" example:"/problems/not-found"`
+	Title     string                `json:"title" xml:"title" example:"Not Found"`
+	Status    int                   `json:"status" xml:"status" example:"404"`
+	Detail    string                `json:"detail,omitempty" xml:"detail,omitempty" example:"pet with ID 7 not found"`
+	Instance  string                `json:"instance,omitempty" xml:"instance,omitempty" example:"/pet/7"`
+	RequestID string                `json:"requestId,omitempty" xml:"requestId,omitempty" example:"3f1c9a6e0b7d4e21"`
+	Errors    []apperror.FieldError `json:"errors,omitempty" xml:"errors>error,omitempty"`
+}
@@ -0,0 +1,101 @@
+package dto
+
+import (
+	"encoding/xml"
+	"petstore/internal/model"
+)
+
+// UserRequest is the body accepted when creating a user. The password is
+// write-only: it is never part of a response. Password strength is checked
+// by the service against the configured policy.
+type UserRequest struct {
+	XMLName   xml.Name `json:"-" xml:"User"`
+	Username  string   `json:"username" xml:"username" example:"johndoe" validate:"required,max=64,noprefix=erased-"`
+	FirstName string   `json:"firstName" xml:"firstName" example:"John" validate:"max=100"`
+	LastName  string   `json:"lastName" xml:"lastName" example:"Doe" validate:"max=100"`
+	Email     string   `json:"email" xml:"email" example:"johndoe@example.com" validate:"email,max=254"`
+	Password  string   `json:"password" xml:"password" example:"secret123" validate:"required"`
+	Phone     string   `json:"phone" xml:"phone" example:"+123456789" validate:"phone"`
+}
+
+// UserUpdateRequest is the body of PUT /user/{username}. The username
+// cannot be changed and an empty password keeps the current one.
+type UserUpdateRequest struct {
+	XMLName   xml.Name `json:"-" xml:"User"`
+	FirstName string   `json:"firstName" xml:"firstName" example:"John" validate:"max=100"`
+	LastName  string   `json:"lastName" xml:"lastName" example:"Doe" validate:"max=100"`
+	Email     string   `json:"email" xml:"email" example:"johndoe@example.com" validate:"email,max=254"`
+	Password  string   `json:"password" xml:"password" example:"secret123"`
+	Phone     string   `json:"phone" xml:"phone" example:"+123456789" validate:"phone"`
+}
+
+type UserResponse struct {
+	XMLName     xml.Name `json:"-" xml:"User"`
+	ID          int64    `json:"id" xml:"id" example:"1"`
+	Username    string   `json:"username" xml:"username" example:"johndoe"`
+	FirstName   string   `json:"firstName" xml:"firstName" example:"John"`
+	LastName    string   `json:"lastName" xml:"lastName" example:"Doe"`
+	Email       string   `json:"email" xml:"email" example:"johndoe@example.com"`
+	Phone       string   `json:"phone" xml:"phone" example:"+123456789"`
+	UserStatus  int      `json:"userStatus" xml:"userStatus" example:"1"`
+	Role        string   `json:"role" xml:"role" example:"customer"`
+	TOTPEnabled bool     `json:"totpEnabled" xml:"totpEnabled" example:"false"`
+}
+
+func (r UserRequest) ToModel() model.User {
+	return model.User{
+		Username:  r.Username,
+		FirstName: r.FirstName,
+		LastName:  r.LastName,
+		Email:     r.Email,
+		Password:  r.Password,
+		Phone:     r.Phone,
+	}
+}
+
+func (r UserUpdateRequest) ToModel() model.User {
+	return model.User{
+		FirstName: r.FirstName,
+		LastName:  r.LastName,
+		Email:     r.Email,
+		Password:  r.Password,
+		Phone:     r.Phone,
+	}
+}
+
+func NewUserResponse(u model.User) UserResponse {
+	return UserResponse{
+		ID:          u.ID,
+		Username:    u.Username,
+		FirstName:   u.FirstName,
+		LastName:    u.LastName,
+		Email:       u.Email,
+		Phone:       u.Phone,
+		UserStatus:  u.UserStatus,
+		Role:        u.Role,
+		TOTPEnabled: u.TOTPEnabled,
+	}
+}
+
+func NewUserResponses(users []model.User) []UserResponse {
+	res := make([]UserResponse, 0, len(users))
+	for _, u := range users {
+		res = append(res, NewUserResponse(u))
+	}
+	return res
+}
+
+type UserBatchItemResult struct {
+	Index    int           `json:"index" xml:"index" example:"0"`
+	Username string        `json:"username" xml:"username" example:"johndoe"`
+	Status   string        `json:"status" xml:"status" example:"created" enums:"created,failed,skipped"`
+	Error    string        `json:"error,omitempty" xml:"error,omitempty" example:"username is required"`
+	User     *UserResponse `json:"user,omitempty" xml:"User,omitempty"`
+}
+
+type UserBatchReport struct {
+	Mode    string                `json:"mode" xml:"mode" example:"atomic"`
+	Created int                   `json:"created" xml:"created" example:"2"`
+	Failed  int                   `json:"failed" xml:"failed" example:"0"`
+	Items   []UserBatchItemResult `json:"items" xml:"items>item"`
+}
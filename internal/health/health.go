@@ -0,0 +1,70 @@
+// Package health runs the readiness checks probed by the orchestrator.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckFunc checks one component. On success it may return a short detail,
+// such as the schema version.
+type CheckFunc func(ctx context.Context) (string, error)
+
+type Result struct {
+	Name   string
+	Detail string
+	Err    error
+}
+
+type Checker struct {
+	timeout      time.Duration
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// NewChecker creates a checker that gives each check at most timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a check. It must not be called once probes are served.
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// ShutDown marks the service not ready, so that load balancers stop sending
+// new requests before the server closes its listener.
+func (c *Checker) ShutDown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) ShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Check runs all checks concurrently and returns their results in the order
+// they were added.
+func (c *Checker) Check(ctx context.Context) []Result {
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, nc := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			detail, err := nc.check(ctx)
+			results[i] = Result{Name: nc.name, Detail: detail, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
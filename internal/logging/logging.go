@@ -0,0 +1,34 @@
+// Package logging builds the application's structured logger and carries a
+// request-scoped logger in context.Context, so every log line written while
+// serving a request can be correlated by its request id.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"petstore/internal/config"
+)
+
+type loggerKey struct{}
+
+func New(cfg config.LogConfig, w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+	if cfg.Format == config.LogFormatText {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx, or the default logger when
+// there is none, e.g. outside of a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"petstore/internal/logging"
+	"sync"
+	"time"
+)
+
+// OutboxMailer keeps sent messages in memory and, if Dir is set, also writes
+// each of them to an .eml file. It is meant for local development.
+type OutboxMailer struct {
+	Dir  string
+	From string
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewOutboxMailer(dir, from string) *OutboxMailer {
+	return &OutboxMailer{Dir: dir, From: from}
+}
+
+func (m *OutboxMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.messages = append(m.messages, msg)
+	n := len(m.messages)
+	m.mu.Unlock()
+
+	if m.Dir == "" {
+		logging.FromContext(ctx).Info("outbox message", "subject", msg.Subject, "to", msg.To)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create outbox dir: %w", err)
+	}
+	name := fmt.Sprintf("%s-%04d.eml", time.Now().UTC().Format("20060102T150405"), n)
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, msg.bytes(m.From), 0o644); err != nil {
+		return fmt.Errorf("failed to write outbox message: %w", err)
+	}
+	logging.FromContext(ctx).Info("outbox message", "subject", msg.Subject, "to", msg.To, "path", path)
+	return nil
+}
+
+func (m *OutboxMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
@@ -0,0 +1,126 @@
+// Package metrics exposes the service's Prometheus metrics. Everything is
+// registered on a registry owned by Metrics rather than the global default,
+// so tests can create as many instances as they like and read them back with
+// prometheus/testutil.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "petstore"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	queryDuration *prometheus.HistogramVec
+	ordersPlaced  prometheus.Counter
+	petsSold      prometheus.Counter
+	loginsFailed  *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_query_duration_seconds",
+			Help:      "Duration of repository calls by repository and method.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"repository", "method"}),
+		ordersPlaced: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_placed_total",
+			Help:      "Orders placed.",
+		}),
+		petsSold: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pets_sold_total",
+			Help:      "Pets whose status changed to sold.",
+		}),
+		loginsFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_failed_total",
+			Help:      "Failed login attempts by reason.",
+		}, []string{"reason"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.queryDuration,
+		m.ordersPlaced,
+		m.petsSold,
+		m.loginsFailed,
+	)
+	return m
+}
+
+// Registry gives tests access to the collected metrics.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterDB exports the connection pool statistics of db.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+func (m *Metrics) ObserveHTTP(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveQuery records the time since start. It takes the start time so that
+// callers can defer it in one line.
+func (m *Metrics) ObserveQuery(repository, method string, start time.Time) {
+	m.queryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+}
+
+func (m *Metrics) OrderPlaced() {
+	m.ordersPlaced.Inc()
+}
+
+func (m *Metrics) PetSold() {
+	m.petsSold.Inc()
+}
+
+// Reasons for LoginFailed.
+const (
+	LoginInvalidCredentials = "invalid_credentials"
+	LoginInvalidTOTP        = "invalid_totp"
+	LoginThrottled          = "throttled"
+	LoginNotAllowed         = "not_allowed"
+)
+
+func (m *Metrics) LoginFailed(reason string) {
+	m.loginsFailed.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"petstore/internal/metrics"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+// unmatchedRoute labels requests no route matched, so that scanners probing
+// random paths can't blow up the number of time series.
+const unmatchedRoute = "unmatched"
+
+// Metrics counts requests and their latency by chi route pattern.
+func Metrics(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+
+			next.ServeHTTP(rec, r)
+
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			m.ObserveHTTP(r.Method, route, rec.statusCode(), time.Since(start))
+		})
+	}
+}
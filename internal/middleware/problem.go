@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"petstore/internal/apperror"
+	"petstore/internal/dto"
+	"petstore/internal/logging"
+	"strings"
+)
+
+var (
+	errAuthenticationRequired = apperror.Unauthorized("authentication required")
+	errInvalidAccessToken     = apperror.Unauthorized("invalid or expired access token")
+	errRoleNotAllowed         = apperror.Forbidden("your role is not allowed to access this resource")
+	errInteractiveLogin       = apperror.Forbidden("api keys cannot access this resource")
+)
+
+var kindStatus = map[apperror.Kind]int{
+	apperror.KindUnauthorized: http.StatusUnauthorized,
+	apperror.KindForbidden:    http.StatusForbidden,
+}
+
+// writeError reports a failed authentication or authorization as an RFC 7807
+// problem, like the responder of the controllers. The responder depends on
+// this package, so the middleware writes the JSON form itself.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var domain interface {
+		error
+		Kind() apperror.Kind
+	}
+	if errors.As(err, &domain) {
+		if status, ok := kindStatus[domain.Kind()]; ok {
+			problem := newProblem(r, status, domain.Error())
+			problem.Type = "/problems/" + strings.ReplaceAll(domain.Kind().String(), " ", "-")
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			writeProblem(w, problem)
+			return
+		}
+	}
+	logging.FromContext(r.Context()).Error("internal error", "err", err)
+	writeProblem(w, newProblem(r, http.StatusInternalServerError, ""))
+}
+
+func newProblem(r *http.Request, status int, detail string) dto.Problem {
+	return dto.Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: GetRequestID(r.Context()),
+	}
+}
+
+func writeProblem(w http.ResponseWriter, problem dto.Problem) {
+	body, _ := json.Marshal(problem)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	w.Write(body)
+}
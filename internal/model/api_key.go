@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// There are no user scopes: accounts and API keys are only managed with an
+// interactive login.
+const (
+	ScopePetRead    = "pet:read"
+	ScopePetWrite   = "pet:write"
+	ScopeStoreRead  = "store:read"
+	ScopeStoreWrite = "store:write"
+)
+
+var AllScopes = []string{
+	ScopePetRead, ScopePetWrite,
+	ScopeStoreRead, ScopeStoreWrite,
+}
+
+type APIKey struct {
+	ID         int64          `db:"id" json:"id" xml:"id" example:"1"`
+	UserID     int64          `db:"user_id" json:"-" xml:"-"`
+	Username   string         `db:"username" json:"username" xml:"username" example:"warehouse-sync"`
+	Role       string         `db:"role" json:"-" xml:"-"`
+	UserStatus int            `db:"user_status" json:"-" xml:"-"`
+	Name       string         `db:"name" json:"name" xml:"name" example:"warehouse sync"`
+	Prefix     string         `db:"prefix" json:"prefix" xml:"prefix" example:"3f9a1c0b7e2d4a65"`
+	SecretHash string         `db:"secret_hash" json:"-" xml:"-"`
+	Scopes     pq.StringArray `db:"scopes" json:"scopes" xml:"scopes>scope" swaggertype:"array,string" example:"pet:read,store:read"`
+	CreatedAt  time.Time      `db:"created_at" json:"createdAt" xml:"createdAt"`
+	LastUsedAt *time.Time     `db:"last_used_at" json:"lastUsedAt" xml:"lastUsedAt"`
+	RevokedAt  *time.Time     `db:"revoked_at" json:"revokedAt" xml:"revokedAt"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" xml:"name" example:"warehouse sync"`
+	Scopes []string `json:"scopes" xml:"scopes>scope" example:"pet:read,store:read"`
+}
+
+// CreatedAPIKey is returned once, when the key is issued. The full key is not
+// stored and cannot be retrieved again.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key" xml:"key" example:"psk_3f9a1c0b7e2d4a65_Zm9vYmFy..."`
+}
+
+// Principal is the authenticated caller of a request. Scopes is nil for
+// interactive (JWT) logins, which are not limited by scope.
+type Principal struct {
+	Username string
+	Role     string
+	APIKeyID int64
+	Scopes   []string
+}
+
+func (p Principal) HasScope(scope string) bool {
+	if p.Scopes == nil {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -9,4 +9,6 @@ type Order struct {
 	ShipDate time.Time `db:"ship_date" json:"shipDate" example:"2025-03-29T15:04:05Z"`
 	Status   string    `db:"status" json:"status" example:"placed"`
 	Complete bool      `db:"complete" json:"complete" example:"false"`
+	// UserID is set when the order was placed by a logged in user.
+	UserID *int64 `db:"user_id" json:"-"`
 }
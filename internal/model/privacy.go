@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+const (
+	ErasureStatusPending  = "pending"
+	ErasureStatusApproved = "approved"
+	ErasureStatusRejected = "rejected"
+)
+
+type ErasureRequest struct {
+	ID          int64      `db:"id" json:"id" xml:"id" example:"1"`
+	UserID      int64      `db:"user_id" json:"userId" xml:"userId" example:"1"`
+	Username    string     `db:"username" json:"username" xml:"username" example:"johndoe"`
+	Status      string     `db:"status" json:"status" xml:"status" example:"pending"`
+	RequestedAt time.Time  `db:"requested_at" json:"requestedAt" xml:"requestedAt"`
+	DecidedAt   *time.Time `db:"decided_at" json:"decidedAt" xml:"decidedAt"`
+	DecidedBy   *string    `db:"decided_by" json:"decidedBy" xml:"decidedBy"`
+}
+
+// UserDataExport is everything stored about one user, as handed out for a
+// data subject access request.
+type UserDataExport struct {
+	ExportedAt      time.Time
+	User            User
+	Orders          []Order
+	APIKeys         []APIKey
+	ErasureRequests []ErasureRequest
+}
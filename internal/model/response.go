@@ -1,7 +1,7 @@
 package model
 
 type ApiResponse struct {
-	Code    int    `json:"code" example:"200"`
-	Type    string `json:"type" example:"success"`
-	Message string `json:"message" example:"operation completed successfully"`
+	Code    int    `json:"code" xml:"code" example:"200"`
+	Type    string `json:"type" xml:"type" example:"success"`
+	Message string `json:"message" xml:"message" example:"operation completed successfully"`
 }
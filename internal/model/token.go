@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+const (
+	TokenPurposePasswordReset = "password_reset"
+	TokenPurposeEmailVerify   = "email_verify"
+	TokenPurposeRecoveryCode  = "recovery_code"
+)
+
+type UserToken struct {
+	ID        int64      `db:"id"`
+	UserID    int64      `db:"user_id"`
+	Purpose   string     `db:"purpose"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt *time.Time `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
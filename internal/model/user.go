@@ -1,12 +1,114 @@
 package model
 
+import (
+	"strings"
+	"time"
+)
+
+const (
+	UserStatusUnverified = 0
+	UserStatusActive     = 1
+	// UserStatusErased marks an account whose personal data was anonymized
+	// after an approved erasure request.
+	UserStatusErased = 2
+	// UserStatusSuspended blocks every login until an admin reactivates the
+	// account.
+	UserStatusSuspended = 3
+)
+
+// ErasedUsernamePrefix starts the name an erased user is renamed to,
+// followed by its ID. No other user may take a name with this prefix.
+const ErasedUsernamePrefix = "erased-"
+
+func IsReservedUsername(username string) bool {
+	return strings.HasPrefix(strings.ToLower(username), ErasedUsernamePrefix)
+}
+
+const (
+	RoleCustomer = "customer"
+	RoleStaff    = "staff"
+	RoleAdmin    = "admin"
+)
+
 type User struct {
 	ID         int64  `db:"id" json:"id" example:"1"`
 	Username   string `db:"username" json:"username" example:"johndoe"`
 	FirstName  string `db:"first_name" json:"firstName" example:"John"`
 	LastName   string `db:"last_name" json:"lastName" example:"Doe"`
 	Email      string `db:"email" json:"email" example:"johndoe@example.com"`
-	Password   string `db:"password" json:"password" example:"secret123"`
+	Password   string `db:"password" json:"-"`
 	Phone      string `db:"phone" json:"phone" example:"+123456789"`
 	UserStatus int    `db:"user_status" json:"userStatus" example:"1"`
+
+	Role string `db:"role" json:"role" example:"customer"`
+
+	TOTPSecret  string `db:"totp_secret" json:"-"`
+	TOTPEnabled bool   `db:"totp_enabled" json:"totpEnabled" example:"false"`
+
+	AuthProvider    string `db:"auth_provider" json:"-"`
+	ExternalSubject string `db:"external_subject" json:"-"`
+
+	LastLoginAt *time.Time `db:"last_login_at" json:"-"`
+	// TokenVersion is part of every access token issued for the user; raising
+	// it revokes the tokens issued before.
+	TokenVersion int64 `db:"token_version" json:"-"`
+}
+
+// UserSearch filters and pages the admin user list. Query matches username,
+// email and name case-insensitively; a nil Status matches every status.
+type UserSearch struct {
+	Query  string
+	Status *int
+	Limit  int
+	Offset int
+}
+
+// ExternalIdentity is a user as asserted by an external identity provider.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Username      string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+	Role          string
+}
+
+type LoginRequest struct {
+	Username string `json:"username" xml:"username" example:"johndoe"`
+	Password string `json:"password" xml:"password" example:"secret123"`
+}
+
+type LoginResult struct {
+	Token             string `json:"token,omitempty" xml:"token,omitempty" example:"eyJhbGciOi..."`
+	TwoFactorRequired bool   `json:"twoFactorRequired,omitempty" xml:"twoFactorRequired,omitempty" example:"false"`
+	ChallengeToken    string `json:"challengeToken,omitempty" xml:"challengeToken,omitempty"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken" xml:"challengeToken" example:"eyJhbGciOi..."`
+	Code           string `json:"code" xml:"code" example:"123456"`
+}
+
+type TOTPEnrollment struct {
+	Secret     string `json:"secret" xml:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OTPAuthURI string `json:"otpauthUri" xml:"otpauthUri" example:"otpauth://totp/Petstore:johndoe?secret=JBSWY3DPEHPK3PXP&issuer=Petstore"`
+}
+
+type TOTPConfirmRequest struct {
+	Code string `json:"code" xml:"code" example:"123456"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes" xml:"recoveryCodes>recoveryCode" example:"abcde-fghij"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" xml:"email" example:"johndoe@example.com"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" xml:"token" example:"q9Xx0c4d..."`
+	Password string `json:"password" xml:"password" example:"newSecret123"`
 }
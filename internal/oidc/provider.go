@@ -0,0 +1,246 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"petstore/internal/apperror"
+	"petstore/internal/model"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+)
+
+var ErrInvalidIDToken = apperror.Unauthorized("invalid id token")
+
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	GroupsClaim  string
+	// RoleMapping maps IdP group names to Petstore roles.
+	RoleMapping map[string]string
+	DefaultRole string
+}
+
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+type Claims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	GivenName         string
+	FamilyName        string
+	Groups            []string
+}
+
+type Provider struct {
+	cfg      Config
+	metadata Metadata
+	client   *http.Client
+	keys     *jwk.AutoRefresh
+}
+
+// Discover loads the provider metadata from the issuer's
+// /.well-known/openid-configuration document.
+func Discover(ctx context.Context, cfg Config, client *http.Client) (*Provider, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+
+	wellKnown := strings.TrimRight(cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch oidc discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery returned %s", resp.Status)
+	}
+
+	var md Metadata
+	if err := json.NewDecoder(resp.Body).Decode(&md); err != nil {
+		return nil, fmt.Errorf("failed to decode oidc discovery document: %w", err)
+	}
+	if md.Issuer != strings.TrimRight(cfg.IssuerURL, "/") && md.Issuer != cfg.IssuerURL {
+		return nil, fmt.Errorf("oidc issuer mismatch: configured %q, discovered %q", cfg.IssuerURL, md.Issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery document is missing required endpoints")
+	}
+
+	keys := jwk.NewAutoRefresh(context.Background())
+	keys.Configure(md.JWKSURI, jwk.WithHTTPClient(client), jwk.WithMinRefreshInterval(15*time.Minute))
+
+	return &Provider{cfg: cfg, metadata: md, client: client, keys: keys}, nil
+}
+
+func (p *Provider) Issuer() string {
+	return p.metadata.Issuer
+}
+
+func (p *Provider) AuthCodeURL(state, nonce, codeChallenge string) string {
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.cfg.ClientID)
+	v.Set("redirect_uri", p.cfg.RedirectURL)
+	v.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", codeChallenge)
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.metadata.AuthorizationEndpoint + sep + v.Encode()
+}
+
+// Exchange redeems an authorization code and returns the raw ID token.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %s: %s", resp.Status, body)
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return "", fmt.Errorf("token response has no id_token")
+	}
+	return tokens.IDToken, nil
+}
+
+// VerifyIDToken checks the signature against the provider's JWKS and
+// validates issuer, audience, expiry and nonce.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (Claims, error) {
+	keySet, err := p.keys.Fetch(ctx, p.metadata.JWKSURI)
+	if err != nil {
+		return Claims{}, fmt.Errorf("failed to fetch oidc signing keys: %w", err)
+	}
+
+	token, err := jwt.ParseString(rawIDToken,
+		jwt.WithKeySet(keySet),
+		jwt.WithValidate(true),
+		jwt.WithIssuer(p.metadata.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithAcceptableSkew(time.Minute),
+	)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	private := token.PrivateClaims()
+	if got, _ := private["nonce"].(string); got == "" || got != nonce {
+		return Claims{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if aud := token.Audience(); len(aud) > 1 {
+		if azp, _ := private["azp"].(string); azp != p.cfg.ClientID {
+			return Claims{}, fmt.Errorf("%w: unexpected authorized party", ErrInvalidIDToken)
+		}
+	}
+	if token.Subject() == "" {
+		return Claims{}, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	claims := Claims{
+		Subject:           token.Subject(),
+		Email:             stringClaim(private, "email"),
+		PreferredUsername: stringClaim(private, "preferred_username"),
+		GivenName:         stringClaim(private, "given_name"),
+		FamilyName:        stringClaim(private, "family_name"),
+		Groups:            stringsClaim(private, p.cfg.GroupsClaim),
+	}
+	claims.EmailVerified, _ = private["email_verified"].(bool)
+
+	return claims, nil
+}
+
+// Role maps the user's groups to the most privileged configured role.
+func (p *Provider) Role(groups []string) string {
+	rank := map[string]int{model.RoleCustomer: 1, model.RoleStaff: 2, model.RoleAdmin: 3}
+
+	role := p.cfg.DefaultRole
+	for _, g := range groups {
+		if mapped, ok := p.cfg.RoleMapping[g]; ok && rank[mapped] > rank[role] {
+			role = mapped
+		}
+	}
+	return role
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
+
+func stringsClaim(claims map[string]interface{}, name string) []string {
+	switch v := claims[name].(type) {
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	case []string:
+		return v
+	case string:
+		return []string{v}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"petstore/internal/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key model.APIKey) (model.APIKey, error)
+	FindByPrefix(ctx context.Context, prefix string) (model.APIKey, error)
+	ListByUser(ctx context.Context, userID int64) ([]model.APIKey, error)
+	Revoke(ctx context.Context, userID, keyID int64) (bool, error)
+	TouchLastUsed(ctx context.Context, keyID int64) error
+}
+
+type apiKeyRepo struct {
+	db      *sqlx.DB
+	timeout time.Duration
+}
+
+func NewAPIKeyRepository(db *sqlx.DB, queryTimeout time.Duration) APIKeyRepository {
+	return &apiKeyRepo{db: db, timeout: queryTimeout}
+}
+
+const apiKeyColumns = `k.id, k.user_id, u.username, u.role, u.user_status, k.name, k.prefix, k.secret_hash, k.scopes,
+	k.created_at, k.last_used_at, k.revoked_at`
+
+func (r *apiKeyRepo) Create(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, secret_hash, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at;
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.SecretHash,
+		key.Scopes,
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return key, fmt.Errorf("failed to insert api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepo) FindByPrefix(ctx context.Context, prefix string) (model.APIKey, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + `
+		FROM api_keys k JOIN users u ON u.id = k.user_id
+		WHERE k.prefix = $1
+	`
+
+	var key model.APIKey
+	err := conn(ctx, r.db).GetContext(ctx, &key, query, prefix)
+	if err != nil {
+		return key, fmt.Errorf("failed to find api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepo) ListByUser(ctx context.Context, userID int64) ([]model.APIKey, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + `
+		FROM api_keys k JOIN users u ON u.id = k.user_id
+		WHERE k.user_id = $1
+		ORDER BY k.id
+	`
+
+	keys := []model.APIKey{}
+	err := conn(ctx, r.db).SelectContext(ctx, &keys, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepo) Revoke(ctx context.Context, userID, keyID int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, keyID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return n > 0, nil
+}
+
+// TouchLastUsed records key usage at most once a minute to keep writes off
+// the hot path.
+func (r *apiKeyRepo) TouchLastUsed(ctx context.Context, keyID int64) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to update api key usage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"petstore/internal/apperror"
+	"petstore/internal/logging"
+	"petstore/internal/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ErasureRepository interface {
+	Create(ctx context.Context, userID int64) (model.ErasureRequest, error)
+	FindByID(ctx context.Context, id int64) (model.ErasureRequest, error)
+	List(ctx context.Context, status string) ([]model.ErasureRequest, error)
+	ListByUser(ctx context.Context, userID int64) ([]model.ErasureRequest, error)
+	Approve(ctx context.Context, id int64, decidedBy string) (model.ErasureRequest, error)
+	Reject(ctx context.Context, id int64, decidedBy string) (model.ErasureRequest, error)
+}
+
+type erasureRepo struct {
+	db      *sqlx.DB
+	timeout time.Duration
+}
+
+func NewErasureRepository(db *sqlx.DB, queryTimeout time.Duration) ErasureRepository {
+	return &erasureRepo{db: db, timeout: queryTimeout}
+}
+
+const erasureColumns = `e.id, e.user_id, u.username, e.status, e.requested_at, e.decided_at, e.decided_by`
+
+func (r *erasureRepo) Create(ctx context.Context, userID int64) (model.ErasureRequest, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO erasure_requests (user_id)
+		VALUES ($1)
+		RETURNING id;
+	`
+
+	var id int64
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&id); err != nil {
+		return model.ErasureRequest{}, fmt.Errorf("failed to insert erasure request: %w", mapUniqueViolation(err, nil))
+	}
+
+	return r.FindByID(ctx, id)
+}
+
+func (r *erasureRepo) FindByID(ctx context.Context, id int64) (model.ErasureRequest, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + erasureColumns + `
+		FROM erasure_requests e JOIN users u ON u.id = e.user_id
+		WHERE e.id = $1`
+
+	var req model.ErasureRequest
+	if err := conn(ctx, r.db).GetContext(ctx, &req, query, id); err != nil {
+		return req, findError(err, apperror.NotFound("erasure request %d not found", id), "find erasure request")
+	}
+	return req, nil
+}
+
+// List returns requests with the given status, or all requests if status is
+// empty, oldest first.
+func (r *erasureRepo) List(ctx context.Context, status string) ([]model.ErasureRequest, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + erasureColumns + `
+		FROM erasure_requests e JOIN users u ON u.id = e.user_id
+		WHERE $1 = '' OR e.status = $1
+		ORDER BY e.requested_at, e.id`
+
+	reqs := []model.ErasureRequest{}
+	if err := conn(ctx, r.db).SelectContext(ctx, &reqs, query, status); err != nil {
+		return nil, fmt.Errorf("failed to list erasure requests: %w", err)
+	}
+	return reqs, nil
+}
+
+func (r *erasureRepo) ListByUser(ctx context.Context, userID int64) ([]model.ErasureRequest, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `SELECT ` + erasureColumns + `
+		FROM erasure_requests e JOIN users u ON u.id = e.user_id
+		WHERE e.user_id = $1
+		ORDER BY e.requested_at, e.id`
+
+	reqs := []model.ErasureRequest{}
+	if err := conn(ctx, r.db).SelectContext(ctx, &reqs, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to list erasure requests: %w", err)
+	}
+	return reqs, nil
+}
+
+// Approve marks a pending request approved and anonymizes the user in the
+// same transaction. Personal fields are blanked and credentials, tokens and
+// API keys removed; the users row itself stays so that orders keep a valid
+// owner for accounting. sql.ErrNoRows is returned if the request is not
+// pending.
+func (r *erasureRepo) Approve(ctx context.Context, id int64, decidedBy string) (model.ErasureRequest, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var userID int64
+	err := inTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		var err error
+		userID, err = decideErasure(ctx, q, id, model.ErasureStatusApproved, decidedBy)
+		if err != nil {
+			return err
+		}
+
+		anonymize := `
+			UPDATE users
+			SET username = $3 || id,
+				first_name = '',
+				last_name = '',
+				email = '',
+				phone = '',
+				password = NULL,
+				totp_secret = NULL,
+				totp_enabled = FALSE,
+				auth_provider = NULL,
+				external_subject = NULL,
+				user_status = $2
+			WHERE id = $1
+		`
+		if _, err := q.ExecContext(ctx, anonymize, userID, model.UserStatusErased, model.ErasedUsernamePrefix); err != nil {
+			return fmt.Errorf("failed to anonymize user: %w", err)
+		}
+		if _, err := q.ExecContext(ctx, `DELETE FROM user_tokens WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete user tokens: %w", err)
+		}
+		if _, err := q.ExecContext(ctx, `DELETE FROM api_keys WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete api keys: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return model.ErasureRequest{}, err
+	}
+	logging.FromContext(ctx).Info("user anonymized", "user_id", userID, "erasure_request_id", id, "decided_by", decidedBy)
+	return r.FindByID(ctx, id)
+}
+
+func (r *erasureRepo) Reject(ctx context.Context, id int64, decidedBy string) (model.ErasureRequest, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if _, err := decideErasure(ctx, conn(ctx, r.db), id, model.ErasureStatusRejected, decidedBy); err != nil {
+		return model.ErasureRequest{}, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+func decideErasure(ctx context.Context, q sqlx.QueryerContext, id int64, status, decidedBy string) (int64, error) {
+	query := `
+		UPDATE erasure_requests
+		SET status = $2, decided_at = NOW(), decided_by = $3
+		WHERE id = $1 AND status = 'pending'
+		RETURNING user_id
+	`
+
+	var userID int64
+	if err := q.QueryRowxContext(ctx, query, id, status, decidedBy).Scan(&userID); err != nil {
+		return 0, fmt.Errorf("failed to decide erasure request: %w", err)
+	}
+	return userID, nil
+}
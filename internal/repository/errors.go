@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"petstore/internal/apperror"
+
+	"github.com/lib/pq"
+)
+
+const (
+	pqUniqueViolation = "23505"
+	pqCheckViolation  = "23514"
+)
+
+// ConflictError reports that a write would violate a uniqueness constraint.
+type ConflictError struct {
+	Field string
+	Value string
+	Err   error
+}
+
+func (e *ConflictError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("%s is already taken", e.Field)
+	}
+	return fmt.Sprintf("%s %q is already taken", e.Field, e.Value)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ConflictError) Kind() apperror.Kind {
+	return apperror.KindConflict
+}
+
+var uniqueConstraintFields = map[string]string{
+	"users_username_lower_key": "username",
+	"users_email_lower_key":    "email",
+	"api_keys_prefix_key":      "api key prefix",
+	// One pending request per user.
+	"idx_erasure_requests_pending": "erasure request",
+}
+
+// reservedConstraintFields are check constraints that keep values free for
+// the application; a violation is reported like a taken value.
+var reservedConstraintFields = map[string]string{
+	"users_username_not_reserved": "username",
+}
+
+// mapUniqueViolation turns a Postgres unique violation, or the violation of
+// a reserved value, into a ConflictError. values supplies the attempted value
+// per field for the error message.
+func mapUniqueViolation(err error, values map[string]string) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	if field, ok := reservedConstraintFields[pqErr.Constraint]; ok && pqErr.Code == pqCheckViolation {
+		return &ConflictError{Field: field, Value: values[field], Err: err}
+	}
+	if pqErr.Code != pqUniqueViolation {
+		return err
+	}
+
+	field, ok := uniqueConstraintFields[pqErr.Constraint]
+	if !ok {
+		field = pqErr.Constraint
+	}
+	return &ConflictError{Field: field, Value: values[field], Err: err}
+}
+
+// findError reports sql.ErrNoRows as the given NotFound error and wraps any
+// other error with the failed action.
+func findError(err error, notFound *apperror.Error, action string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound.Wrap(err)
+	}
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
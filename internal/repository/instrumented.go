@@ -0,0 +1,423 @@
+package repository
+
+import (
+	"context"
+	"petstore/internal/metrics"
+	"petstore/internal/model"
+	"petstore/internal/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// observer records a repository call as a client span and in the
+// repository_query_duration_seconds histogram.
+type observer struct {
+	repository string
+	metrics    *metrics.Metrics
+}
+
+func (o observer) start(ctx context.Context, method string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, o.repository+"Repo."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.collection.name", o.repository),
+			attribute.String("db.operation.name", method),
+		),
+	)
+	return ctx, func(err error) {
+		o.metrics.ObserveQuery(o.repository, method, start)
+		tracing.End(span, err)
+	}
+}
+
+type instrumentedPetRepo struct {
+	next PetRepository
+	observer
+}
+
+// InstrumentPetRepository traces and times every call to next.
+func InstrumentPetRepository(next PetRepository, m *metrics.Metrics) PetRepository {
+	return &instrumentedPetRepo{next: next, observer: observer{repository: "pet", metrics: m}}
+}
+
+func (r *instrumentedPetRepo) Create(ctx context.Context, pet model.Pet) (model.Pet, error) {
+	ctx, done := r.start(ctx, "Create")
+	res, err := r.next.Create(ctx, pet)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedPetRepo) Update(ctx context.Context, pet model.Pet) (model.Pet, error) {
+	ctx, done := r.start(ctx, "Update")
+	res, err := r.next.Update(ctx, pet)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedPetRepo) UpdateFormData(ctx context.Context, petID int, name, status string) (model.Pet, error) {
+	ctx, done := r.start(ctx, "UpdateFormData")
+	res, err := r.next.UpdateFormData(ctx, petID, name, status)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedPetRepo) FindByID(ctx context.Context, petID int) (model.Pet, error) {
+	ctx, done := r.start(ctx, "FindByID")
+	res, err := r.next.FindByID(ctx, petID)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedPetRepo) FindByStatus(ctx context.Context, statuses []string) ([]model.Pet, error) {
+	ctx, done := r.start(ctx, "FindByStatus")
+	res, err := r.next.FindByStatus(ctx, statuses)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedPetRepo) FindByTags(ctx context.Context, tags []string) ([]model.Pet, error) {
+	ctx, done := r.start(ctx, "FindByTags")
+	res, err := r.next.FindByTags(ctx, tags)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedPetRepo) Delete(ctx context.Context, petID int) error {
+	ctx, done := r.start(ctx, "Delete")
+	err := r.next.Delete(ctx, petID)
+	done(err)
+	return err
+}
+
+func (r *instrumentedPetRepo) ExistsByID(ctx context.Context, petID int) (bool, error) {
+	ctx, done := r.start(ctx, "ExistsByID")
+	res, err := r.next.ExistsByID(ctx, petID)
+	done(err)
+	return res, err
+}
+
+type instrumentedOrderRepo struct {
+	next OrderRepository
+	observer
+}
+
+// InstrumentOrderRepository traces and times every call to next.
+func InstrumentOrderRepository(next OrderRepository, m *metrics.Metrics) OrderRepository {
+	return &instrumentedOrderRepo{next: next, observer: observer{repository: "order", metrics: m}}
+}
+
+func (r *instrumentedOrderRepo) Create(ctx context.Context, order model.Order) (model.Order, error) {
+	ctx, done := r.start(ctx, "Create")
+	res, err := r.next.Create(ctx, order)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedOrderRepo) FindByID(ctx context.Context, orderID int) (model.Order, error) {
+	ctx, done := r.start(ctx, "FindByID")
+	res, err := r.next.FindByID(ctx, orderID)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedOrderRepo) ListByUser(ctx context.Context, userID int64) ([]model.Order, error) {
+	ctx, done := r.start(ctx, "ListByUser")
+	res, err := r.next.ListByUser(ctx, userID)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedOrderRepo) Delete(ctx context.Context, orderID int) error {
+	ctx, done := r.start(ctx, "Delete")
+	err := r.next.Delete(ctx, orderID)
+	done(err)
+	return err
+}
+
+func (r *instrumentedOrderRepo) GetInventory(ctx context.Context) (map[string]int, error) {
+	ctx, done := r.start(ctx, "GetInventory")
+	res, err := r.next.GetInventory(ctx)
+	done(err)
+	return res, err
+}
+
+type instrumentedUserRepo struct {
+	next UserRepository
+	observer
+}
+
+// InstrumentUserRepository traces and times every call to next.
+func InstrumentUserRepository(next UserRepository, m *metrics.Metrics) UserRepository {
+	return &instrumentedUserRepo{next: next, observer: observer{repository: "user", metrics: m}}
+}
+
+func (r *instrumentedUserRepo) Create(ctx context.Context, user model.User) (model.User, error) {
+	ctx, done := r.start(ctx, "Create")
+	res, err := r.next.Create(ctx, user)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedUserRepo) CreateBatch(ctx context.Context, users []model.User) ([]model.User, error) {
+	ctx, done := r.start(ctx, "CreateBatch")
+	res, err := r.next.CreateBatch(ctx, users)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedUserRepo) CreateEach(ctx context.Context, users []model.User) ([]model.User, []error, error) {
+	ctx, done := r.start(ctx, "CreateEach")
+	created, errs, err := r.next.CreateEach(ctx, users)
+	done(err)
+	return created, errs, err
+}
+
+func (r *instrumentedUserRepo) ExistingUsernames(ctx context.Context, usernames []string) (map[string]bool, error) {
+	ctx, done := r.start(ctx, "ExistingUsernames")
+	res, err := r.next.ExistingUsernames(ctx, usernames)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedUserRepo) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	ctx, done := r.start(ctx, "ExistingEmails")
+	res, err := r.next.ExistingEmails(ctx, emails)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedUserRepo) FindByUsername(ctx context.Context, username string) (model.User, error) {
+	ctx, done := r.start(ctx, "FindByUsername")
+	res, err := r.next.FindByUsername(ctx, username)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedUserRepo) FindByID(ctx context.Context, id int64) (model.User, error) {
+	ctx, done := r.start(ctx, "FindByID")
+	res, err := r.next.FindByID(ctx, id)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedUserRepo) FindByEmail(ctx context.Context, email string) (model.User, error) {
+	ctx, done := r.start(ctx, "FindByEmail")
+	res, err := r.next.FindByEmail(ctx, email)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedUserRepo) FindByExternalIdentity(ctx context.Context, provider, subject string) (model.User, error) {
+	ctx, done := r.start(ctx, "FindByExternalIdentity")
+	res, err := r.next.FindByExternalIdentity(ctx, provider, subject)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedUserRepo) Search(ctx context.Context, search model.UserSearch) ([]model.User, int, error) {
+	ctx, done := r.start(ctx, "Search")
+	users, total, err := r.next.Search(ctx, search)
+	done(err)
+	return users, total, err
+}
+
+func (r *instrumentedUserRepo) Update(ctx context.Context, username string, user model.User) (model.User, error) {
+	ctx, done := r.start(ctx, "Update")
+	res, err := r.next.Update(ctx, username, user)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedUserRepo) UpdatePassword(ctx context.Context, id int64, passwordHash string) error {
+	ctx, done := r.start(ctx, "UpdatePassword")
+	err := r.next.UpdatePassword(ctx, id, passwordHash)
+	done(err)
+	return err
+}
+
+func (r *instrumentedUserRepo) RevokeAccessTokens(ctx context.Context, id int64) error {
+	ctx, done := r.start(ctx, "RevokeAccessTokens")
+	err := r.next.RevokeAccessTokens(ctx, id)
+	done(err)
+	return err
+}
+
+func (r *instrumentedUserRepo) UpdateStatus(ctx context.Context, id int64, status int) error {
+	ctx, done := r.start(ctx, "UpdateStatus")
+	err := r.next.UpdateStatus(ctx, id, status)
+	done(err)
+	return err
+}
+
+func (r *instrumentedUserRepo) UpdateLastLogin(ctx context.Context, id int64) error {
+	ctx, done := r.start(ctx, "UpdateLastLogin")
+	err := r.next.UpdateLastLogin(ctx, id)
+	done(err)
+	return err
+}
+
+func (r *instrumentedUserRepo) UpdateTOTP(ctx context.Context, id int64, secret string, enabled bool) error {
+	ctx, done := r.start(ctx, "UpdateTOTP")
+	err := r.next.UpdateTOTP(ctx, id, secret, enabled)
+	done(err)
+	return err
+}
+
+func (r *instrumentedUserRepo) UseTOTPStep(ctx context.Context, id int64, step int64) (bool, error) {
+	ctx, done := r.start(ctx, "UseTOTPStep")
+	ok, err := r.next.UseTOTPStep(ctx, id, step)
+	done(err)
+	return ok, err
+}
+
+func (r *instrumentedUserRepo) UpdateExternalIdentity(ctx context.Context, id int64, identity model.ExternalIdentity) error {
+	ctx, done := r.start(ctx, "UpdateExternalIdentity")
+	err := r.next.UpdateExternalIdentity(ctx, id, identity)
+	done(err)
+	return err
+}
+
+func (r *instrumentedUserRepo) Delete(ctx context.Context, username string) error {
+	ctx, done := r.start(ctx, "Delete")
+	err := r.next.Delete(ctx, username)
+	done(err)
+	return err
+}
+
+type instrumentedTokenRepo struct {
+	next TokenRepository
+	observer
+}
+
+// InstrumentTokenRepository traces and times every call to next.
+func InstrumentTokenRepository(next TokenRepository, m *metrics.Metrics) TokenRepository {
+	return &instrumentedTokenRepo{next: next, observer: observer{repository: "token", metrics: m}}
+}
+
+func (r *instrumentedTokenRepo) Create(ctx context.Context, token model.UserToken) (model.UserToken, error) {
+	ctx, done := r.start(ctx, "Create")
+	res, err := r.next.Create(ctx, token)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedTokenRepo) Consume(ctx context.Context, purpose, tokenHash string) (model.UserToken, error) {
+	ctx, done := r.start(ctx, "Consume")
+	res, err := r.next.Consume(ctx, purpose, tokenHash)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedTokenRepo) ConsumeForUser(ctx context.Context, userID int64, purpose, tokenHash string) (model.UserToken, error) {
+	ctx, done := r.start(ctx, "ConsumeForUser")
+	res, err := r.next.ConsumeForUser(ctx, userID, purpose, tokenHash)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedTokenRepo) DeleteByUser(ctx context.Context, userID int64, purpose string) error {
+	ctx, done := r.start(ctx, "DeleteByUser")
+	err := r.next.DeleteByUser(ctx, userID, purpose)
+	done(err)
+	return err
+}
+
+type instrumentedAPIKeyRepo struct {
+	next APIKeyRepository
+	observer
+}
+
+// InstrumentAPIKeyRepository traces and times every call to next.
+func InstrumentAPIKeyRepository(next APIKeyRepository, m *metrics.Metrics) APIKeyRepository {
+	return &instrumentedAPIKeyRepo{next: next, observer: observer{repository: "api_key", metrics: m}}
+}
+
+func (r *instrumentedAPIKeyRepo) Create(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	ctx, done := r.start(ctx, "Create")
+	res, err := r.next.Create(ctx, key)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedAPIKeyRepo) FindByPrefix(ctx context.Context, prefix string) (model.APIKey, error) {
+	ctx, done := r.start(ctx, "FindByPrefix")
+	res, err := r.next.FindByPrefix(ctx, prefix)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedAPIKeyRepo) ListByUser(ctx context.Context, userID int64) ([]model.APIKey, error) {
+	ctx, done := r.start(ctx, "ListByUser")
+	res, err := r.next.ListByUser(ctx, userID)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedAPIKeyRepo) Revoke(ctx context.Context, userID, keyID int64) (bool, error) {
+	ctx, done := r.start(ctx, "Revoke")
+	res, err := r.next.Revoke(ctx, userID, keyID)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedAPIKeyRepo) TouchLastUsed(ctx context.Context, keyID int64) error {
+	ctx, done := r.start(ctx, "TouchLastUsed")
+	err := r.next.TouchLastUsed(ctx, keyID)
+	done(err)
+	return err
+}
+
+type instrumentedErasureRepo struct {
+	next ErasureRepository
+	observer
+}
+
+// InstrumentErasureRepository traces and times every call to next.
+func InstrumentErasureRepository(next ErasureRepository, m *metrics.Metrics) ErasureRepository {
+	return &instrumentedErasureRepo{next: next, observer: observer{repository: "erasure", metrics: m}}
+}
+
+func (r *instrumentedErasureRepo) Create(ctx context.Context, userID int64) (model.ErasureRequest, error) {
+	ctx, done := r.start(ctx, "Create")
+	res, err := r.next.Create(ctx, userID)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedErasureRepo) FindByID(ctx context.Context, id int64) (model.ErasureRequest, error) {
+	ctx, done := r.start(ctx, "FindByID")
+	res, err := r.next.FindByID(ctx, id)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedErasureRepo) List(ctx context.Context, status string) ([]model.ErasureRequest, error) {
+	ctx, done := r.start(ctx, "List")
+	res, err := r.next.List(ctx, status)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedErasureRepo) ListByUser(ctx context.Context, userID int64) ([]model.ErasureRequest, error) {
+	ctx, done := r.start(ctx, "ListByUser")
+	res, err := r.next.ListByUser(ctx, userID)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedErasureRepo) Approve(ctx context.Context, id int64, decidedBy string) (model.ErasureRequest, error) {
+	ctx, done := r.start(ctx, "Approve")
+	res, err := r.next.Approve(ctx, id, decidedBy)
+	done(err)
+	return res, err
+}
+
+func (r *instrumentedErasureRepo) Reject(ctx context.Context, id int64, decidedBy string) (model.ErasureRequest, error) {
+	ctx, done := r.start(ctx, "Reject")
+	res, err := r.next.Reject(ctx, id, decidedBy)
+	done(err)
+	return res, err
+}
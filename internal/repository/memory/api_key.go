@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"petstore/internal/model"
+	"petstore/internal/repository"
+	"slices"
+	"time"
+)
+
+type apiKeyRepo struct {
+	s *Store
+}
+
+func NewAPIKeyRepository(s *Store) repository.APIKeyRepository {
+	return &apiKeyRepo{s: s}
+}
+
+func (r *apiKeyRepo) Create(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	defer r.s.lock(ctx)()
+
+	if _, ok := r.s.users[key.UserID]; !ok {
+		return key, fmt.Errorf("failed to insert api key: user %d does not exist", key.UserID)
+	}
+	for _, other := range r.s.apiKeys {
+		if other.Prefix == key.Prefix {
+			return key, fmt.Errorf("failed to insert api key: %w",
+				&repository.ConflictError{Field: "api key prefix", Value: key.Prefix})
+		}
+	}
+
+	r.s.lastAPIKeyID++
+	key.ID = r.s.lastAPIKeyID
+	key.CreatedAt = time.Now()
+	key.Scopes = slices.Clone(key.Scopes)
+	r.s.apiKeys[key.ID] = key
+	return key, nil
+}
+
+func (r *apiKeyRepo) FindByPrefix(_ context.Context, prefix string) (model.APIKey, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, key := range r.s.apiKeys {
+		if key.Prefix == prefix {
+			return r.s.withOwner(key), nil
+		}
+	}
+	return model.APIKey{}, fmt.Errorf("failed to find api key: %w", sql.ErrNoRows)
+}
+
+func (r *apiKeyRepo) ListByUser(_ context.Context, userID int64) ([]model.APIKey, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	keys := []model.APIKey{}
+	for _, key := range r.s.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, r.s.withOwner(key))
+		}
+	}
+	slices.SortFunc(keys, func(a, b model.APIKey) int { return int(a.ID - b.ID) })
+	return keys, nil
+}
+
+func (r *apiKeyRepo) Revoke(ctx context.Context, userID, keyID int64) (bool, error) {
+	defer r.s.lock(ctx)()
+
+	key, ok := r.s.apiKeys[keyID]
+	if !ok || key.UserID != userID || key.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	r.s.apiKeys[keyID] = key
+	return true, nil
+}
+
+// TouchLastUsed records key usage at most once a minute, like the SQL
+// repository.
+func (r *apiKeyRepo) TouchLastUsed(ctx context.Context, keyID int64) error {
+	defer r.s.lock(ctx)()
+
+	now := time.Now()
+	key, ok := r.s.apiKeys[keyID]
+	if ok && (key.LastUsedAt == nil || key.LastUsedAt.Before(now.Add(-time.Minute))) {
+		key.LastUsedAt = &now
+		r.s.apiKeys[keyID] = key
+	}
+	return nil
+}
+
+// withOwner fills in the fields of the key that come from its user. The
+// caller holds the lock.
+func (s *Store) withOwner(key model.APIKey) model.APIKey {
+	user := s.users[key.UserID]
+	key.Username = user.Username
+	key.Role = user.Role
+	key.UserStatus = user.UserStatus
+	key.Scopes = slices.Clone(key.Scopes)
+	return key
+}
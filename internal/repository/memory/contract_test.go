@@ -0,0 +1,18 @@
+package memory_test
+
+import (
+	"petstore/internal/repository/memory"
+	"petstore/internal/repository/repotest"
+	"testing"
+)
+
+func TestContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		store := memory.NewStore()
+		return repotest.Repositories{
+			Pets:   memory.NewPetRepository(store),
+			Orders: memory.NewOrderRepository(store),
+			Users:  memory.NewUserRepository(store),
+		}
+	})
+}
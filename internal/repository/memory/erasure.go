@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"petstore/internal/logging"
+	"petstore/internal/model"
+	"petstore/internal/repository"
+	"slices"
+	"strconv"
+	"time"
+)
+
+type erasureRepo struct {
+	s *Store
+}
+
+func NewErasureRepository(s *Store) repository.ErasureRepository {
+	return &erasureRepo{s: s}
+}
+
+func (r *erasureRepo) Create(ctx context.Context, userID int64) (model.ErasureRequest, error) {
+	unlock := r.s.lock(ctx)
+	if _, ok := r.s.users[userID]; !ok {
+		unlock()
+		return model.ErasureRequest{}, fmt.Errorf("failed to insert erasure request: user %d does not exist", userID)
+	}
+	for _, req := range r.s.erasures {
+		if req.UserID == userID && req.Status == model.ErasureStatusPending {
+			unlock()
+			return model.ErasureRequest{}, fmt.Errorf("failed to insert erasure request: %w",
+				&repository.ConflictError{Field: "erasure request"})
+		}
+	}
+	r.s.lastErasureID++
+	id := r.s.lastErasureID
+	r.s.erasures[id] = model.ErasureRequest{
+		ID:          id,
+		UserID:      userID,
+		Status:      model.ErasureStatusPending,
+		RequestedAt: time.Now(),
+	}
+	unlock()
+
+	return r.FindByID(ctx, id)
+}
+
+func (r *erasureRepo) FindByID(_ context.Context, id int64) (model.ErasureRequest, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	req, ok := r.s.erasures[id]
+	if !ok {
+		return req, notFound("erasure request %d not found", id)
+	}
+	return r.s.withRequester(req), nil
+}
+
+// List returns requests with the given status, or all requests if status is
+// empty, oldest first.
+func (r *erasureRepo) List(_ context.Context, status string) ([]model.ErasureRequest, error) {
+	return r.filter(func(req model.ErasureRequest) bool {
+		return status == "" || req.Status == status
+	}), nil
+}
+
+func (r *erasureRepo) ListByUser(_ context.Context, userID int64) ([]model.ErasureRequest, error) {
+	return r.filter(func(req model.ErasureRequest) bool {
+		return req.UserID == userID
+	}), nil
+}
+
+func (r *erasureRepo) filter(match func(model.ErasureRequest) bool) []model.ErasureRequest {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	reqs := []model.ErasureRequest{}
+	for _, req := range r.s.erasures {
+		if match(req) {
+			reqs = append(reqs, r.s.withRequester(req))
+		}
+	}
+	slices.SortFunc(reqs, func(a, b model.ErasureRequest) int {
+		return cmp.Or(a.RequestedAt.Compare(b.RequestedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return reqs
+}
+
+// Approve marks a pending request approved and anonymizes the user, keeping
+// the user itself so that orders keep their owner. sql.ErrNoRows is returned
+// if the request is not pending.
+func (r *erasureRepo) Approve(ctx context.Context, id int64, decidedBy string) (model.ErasureRequest, error) {
+	unlock := r.s.lock(ctx)
+	userID, err := r.s.decideErasure(id, model.ErasureStatusApproved, decidedBy)
+	if err != nil {
+		unlock()
+		return model.ErasureRequest{}, err
+	}
+	if user, ok := r.s.users[userID]; ok {
+		r.s.users[userID] = model.User{
+			ID:          user.ID,
+			Username:    model.ErasedUsernamePrefix + strconv.FormatInt(user.ID, 10),
+			UserStatus:  model.UserStatusErased,
+			Role:        user.Role,
+			LastLoginAt: user.LastLoginAt,
+		}
+	}
+	r.s.deleteCredentials(userID)
+	unlock()
+
+	logging.FromContext(ctx).Info("user anonymized", "user_id", userID, "erasure_request_id", id, "decided_by", decidedBy)
+	return r.FindByID(ctx, id)
+}
+
+func (r *erasureRepo) Reject(ctx context.Context, id int64, decidedBy string) (model.ErasureRequest, error) {
+	unlock := r.s.lock(ctx)
+	_, err := r.s.decideErasure(id, model.ErasureStatusRejected, decidedBy)
+	unlock()
+	if err != nil {
+		return model.ErasureRequest{}, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// decideErasure sets the status of a pending request and returns its user.
+// The caller holds the write lock.
+func (s *Store) decideErasure(id int64, status, decidedBy string) (int64, error) {
+	req, ok := s.erasures[id]
+	if !ok || req.Status != model.ErasureStatusPending {
+		return 0, fmt.Errorf("failed to decide erasure request: %w", sql.ErrNoRows)
+	}
+	now := time.Now()
+	req.Status = status
+	req.DecidedAt = &now
+	req.DecidedBy = &decidedBy
+	s.erasures[id] = req
+	return req.UserID, nil
+}
+
+// withRequester fills in the username of the request. The caller holds the
+// lock.
+func (s *Store) withRequester(req model.ErasureRequest) model.ErasureRequest {
+	req.Username = s.users[req.UserID].Username
+	return req
+}
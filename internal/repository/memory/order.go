@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"petstore/internal/apperror"
+	"petstore/internal/model"
+	"petstore/internal/repository"
+	"slices"
+)
+
+type orderRepo struct {
+	s *Store
+}
+
+func NewOrderRepository(s *Store) repository.OrderRepository {
+	return &orderRepo{s: s}
+}
+
+func (r *orderRepo) Create(ctx context.Context, order model.Order) (model.Order, error) {
+	defer r.s.lock(ctx)()
+
+	if _, ok := r.s.pets[order.PetID]; !ok {
+		return order, fmt.Errorf("failed to insert order: pet %d does not exist", order.PetID)
+	}
+	if order.UserID != nil {
+		if _, ok := r.s.users[*order.UserID]; !ok {
+			return order, fmt.Errorf("failed to insert order: user %d does not exist", *order.UserID)
+		}
+	}
+
+	r.s.lastOrderID++
+	order.ID = r.s.lastOrderID
+	r.s.orders[order.ID] = cloneOrder(order)
+	return order, nil
+}
+
+func (r *orderRepo) FindByID(_ context.Context, orderID int) (model.Order, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	order, ok := r.s.orders[orderID]
+	if !ok {
+		return model.Order{}, notFound("order with ID %d not found", orderID)
+	}
+	return cloneOrder(order), nil
+}
+
+func (r *orderRepo) ListByUser(_ context.Context, userID int64) ([]model.Order, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	orders := []model.Order{}
+	for _, order := range r.s.orders {
+		if order.UserID != nil && *order.UserID == userID {
+			orders = append(orders, cloneOrder(order))
+		}
+	}
+	slices.SortFunc(orders, func(a, b model.Order) int { return a.ID - b.ID })
+	return orders, nil
+}
+
+func (r *orderRepo) Delete(ctx context.Context, orderID int) error {
+	defer r.s.lock(ctx)()
+
+	order, ok := r.s.orders[orderID]
+	if !ok {
+		return notFound("order with ID %d not found", orderID)
+	}
+	if order.Status == "delivered" {
+		return apperror.Conflict("cannot delete a completed order")
+	}
+	delete(r.s.orders, orderID)
+	return nil
+}
+
+func (r *orderRepo) GetInventory(_ context.Context) (map[string]int, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	inventory := make(map[string]int)
+	for _, order := range r.s.orders {
+		inventory[order.Status]++
+	}
+	return inventory, nil
+}
+
+func cloneOrder(order model.Order) model.Order {
+	if order.UserID != nil {
+		userID := *order.UserID
+		order.UserID = &userID
+	}
+	return order
+}
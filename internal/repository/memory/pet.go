@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"petstore/internal/model"
+	"petstore/internal/repository"
+	"slices"
+)
+
+type petRepo struct {
+	s *Store
+}
+
+func NewPetRepository(s *Store) repository.PetRepository {
+	return &petRepo{s: s}
+}
+
+func (r *petRepo) Create(ctx context.Context, pet model.Pet) (model.Pet, error) {
+	defer r.s.lock(ctx)()
+
+	r.s.lastPetID++
+	pet.ID = r.s.lastPetID
+	r.s.pets[pet.ID] = clonePet(pet)
+	return pet, nil
+}
+
+func (r *petRepo) Update(ctx context.Context, pet model.Pet) (model.Pet, error) {
+	defer r.s.lock(ctx)()
+
+	if _, ok := r.s.pets[pet.ID]; ok {
+		r.s.pets[pet.ID] = clonePet(pet)
+	}
+	return pet, nil
+}
+
+func (r *petRepo) UpdateFormData(ctx context.Context, petID int, name, status string) (model.Pet, error) {
+	unlock := r.s.lock(ctx)
+	if pet, ok := r.s.pets[petID]; ok {
+		pet.Name, pet.Status = name, status
+		r.s.pets[petID] = pet
+	}
+	unlock()
+
+	pet, err := r.FindByID(ctx, petID)
+	if err != nil {
+		return model.Pet{}, fmt.Errorf("failed to retrieve updated pet: %w", err)
+	}
+	return pet, nil
+}
+
+func (r *petRepo) FindByID(_ context.Context, petID int) (model.Pet, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	pet, ok := r.s.pets[petID]
+	if !ok {
+		return model.Pet{}, notFound("pet with ID %d not found", petID)
+	}
+	return clonePet(pet), nil
+}
+
+func (r *petRepo) FindByStatus(_ context.Context, statuses []string) ([]model.Pet, error) {
+	return r.filter(func(pet model.Pet) bool {
+		return slices.Contains(statuses, pet.Status)
+	}), nil
+}
+
+func (r *petRepo) FindByTags(_ context.Context, tags []string) ([]model.Pet, error) {
+	return r.filter(func(pet model.Pet) bool {
+		return slices.ContainsFunc(pet.Tags, func(tag model.Tag) bool {
+			return slices.Contains(tags, tag.Name)
+		})
+	}), nil
+}
+
+// filter returns the matching pets ordered by ID.
+func (r *petRepo) filter(match func(model.Pet) bool) []model.Pet {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	pets := make([]model.Pet, 0)
+	for _, pet := range r.s.pets {
+		if match(pet) {
+			pets = append(pets, clonePet(pet))
+		}
+	}
+	slices.SortFunc(pets, func(a, b model.Pet) int { return a.ID - b.ID })
+	return pets
+}
+
+// Delete removes the pet and, like the foreign key of the orders table, its
+// orders.
+func (r *petRepo) Delete(ctx context.Context, petID int) error {
+	defer r.s.lock(ctx)()
+
+	if _, ok := r.s.pets[petID]; !ok {
+		return notFound("pet with ID %d not found", petID)
+	}
+	delete(r.s.pets, petID)
+	for id, order := range r.s.orders {
+		if order.PetID == petID {
+			delete(r.s.orders, id)
+		}
+	}
+	return nil
+}
+
+func (r *petRepo) ExistsByID(_ context.Context, petID int) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	_, ok := r.s.pets[petID]
+	return ok, nil
+}
+
+// clonePet copies the slices of pet, so that callers can't change stored
+// pets.
+func clonePet(pet model.Pet) model.Pet {
+	pet.PhotoUrls = slices.Clone(pet.PhotoUrls)
+	pet.Tags = slices.Clone(pet.Tags)
+	return pet
+}
@@ -0,0 +1,150 @@
+// Package memory implements the repositories on in-process maps, for tests
+// and for running the API without a database. The repositories of one Store
+// share its data, so relations such as the owner of an API key or the
+// cascading deletes of the Postgres schema behave the same. Data is lost when
+// the process exits.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+	"petstore/internal/apperror"
+	"petstore/internal/model"
+	"petstore/internal/repository"
+	"sync"
+)
+
+// Store holds the data of all memory repositories. It is safe for concurrent
+// use.
+type Store struct {
+	mu sync.RWMutex
+
+	pets     map[int]model.Pet
+	orders   map[int]model.Order
+	users    map[int64]model.User
+	tokens   map[int64]model.UserToken
+	apiKeys  map[int64]model.APIKey
+	erasures map[int64]model.ErasureRequest
+
+	// totpSteps is the last accepted TOTP time step per user.
+	totpSteps map[int64]int64
+
+	lastPetID     int
+	lastOrderID   int
+	lastUserID    int64
+	lastTokenID   int64
+	lastAPIKeyID  int64
+	lastErasureID int64
+
+	// txMu serializes transactions and the writes outside of them, see
+	// NewTxManager.
+	txMu sync.Mutex
+}
+
+func NewStore() *Store {
+	return &Store{
+		pets:     make(map[int]model.Pet),
+		orders:   make(map[int]model.Order),
+		users:    make(map[int64]model.User),
+		tokens:   make(map[int64]model.UserToken),
+		apiKeys:  make(map[int64]model.APIKey),
+		erasures: make(map[int64]model.ErasureRequest),
+
+		totpSteps: make(map[int64]int64),
+	}
+}
+
+type txKey struct{}
+
+type txManager struct {
+	s *Store
+}
+
+// NewTxManager returns a TxManager that runs one transaction of the store at
+// a time and rolls back its writes if it fails. Writes outside of
+// transactions wait for the running transaction to end, so a rollback never
+// undoes them. Reads do not wait and may see writes that are rolled back
+// later.
+func NewTxManager(s *Store) repository.TxManager {
+	return &txManager{s: s}
+}
+
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+	m.s.txMu.Lock()
+	defer m.s.txMu.Unlock()
+
+	m.s.mu.RLock()
+	saved := m.s.snapshot()
+	m.s.mu.RUnlock()
+
+	committed := false
+	defer func() {
+		if !committed {
+			m.s.mu.Lock()
+			m.s.restore(saved)
+			m.s.mu.Unlock()
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, true)); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// lock takes the write lock for a change made with ctx. Outside of a
+// transaction it first waits for the running transaction, if any, to end.
+// The returned function releases the locks.
+func (s *Store) lock(ctx context.Context) func() {
+	if ctx.Value(txKey{}) != nil {
+		s.mu.Lock()
+		return s.mu.Unlock
+	}
+	s.txMu.Lock()
+	s.mu.Lock()
+	return func() {
+		s.mu.Unlock()
+		s.txMu.Unlock()
+	}
+}
+
+// snapshot copies the data of the store for a rollback. Stored values are
+// replaced rather than changed in place, so copying the maps is enough. The
+// caller holds the lock.
+func (s *Store) snapshot() *Store {
+	return &Store{
+		pets:          maps.Clone(s.pets),
+		orders:        maps.Clone(s.orders),
+		users:         maps.Clone(s.users),
+		tokens:        maps.Clone(s.tokens),
+		apiKeys:       maps.Clone(s.apiKeys),
+		erasures:      maps.Clone(s.erasures),
+		totpSteps:     maps.Clone(s.totpSteps),
+		lastPetID:     s.lastPetID,
+		lastOrderID:   s.lastOrderID,
+		lastUserID:    s.lastUserID,
+		lastTokenID:   s.lastTokenID,
+		lastAPIKeyID:  s.lastAPIKeyID,
+		lastErasureID: s.lastErasureID,
+	}
+}
+
+// restore puts back the data of a snapshot. The caller holds the write lock.
+func (s *Store) restore(saved *Store) {
+	s.pets, s.orders, s.users = saved.pets, saved.orders, saved.users
+	s.tokens, s.apiKeys, s.erasures = saved.tokens, saved.apiKeys, saved.erasures
+	s.totpSteps = saved.totpSteps
+	s.lastPetID, s.lastOrderID, s.lastUserID = saved.lastPetID, saved.lastOrderID, saved.lastUserID
+	s.lastTokenID, s.lastAPIKeyID, s.lastErasureID = saved.lastTokenID, saved.lastAPIKeyID, saved.lastErasureID
+}
+
+// notFound wraps sql.ErrNoRows like the SQL repositories do, as services
+// check for it.
+func notFound(format string, args ...interface{}) error {
+	return apperror.NotFound(format, args...).Wrap(sql.ErrNoRows)
+}
@@ -0,0 +1,115 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"petstore/internal/model"
+	"petstore/internal/repository/memory"
+	"testing"
+	"time"
+)
+
+func TestWithinTxRollsBackOnError(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	tx := memory.NewTxManager(store)
+	pets := memory.NewPetRepository(store)
+
+	kept, err := pets.Create(ctx, model.Pet{Name: "Rex", Status: "available"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errFailed := errors.New("failed")
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := pets.Create(ctx, model.Pet{Name: "Tom", Status: "available"}); err != nil {
+			return err
+		}
+		kept.Status = "sold"
+		if _, err := pets.Update(ctx, kept); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("got error %v, want %v", err, errFailed)
+	}
+
+	all, err := pets.FindByStatus(ctx, []string{"available", "sold"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].Status != "available" {
+		t.Errorf("after rollback got pets %+v, want only %q available", all, kept.Name)
+	}
+
+	// The ID of the rolled back pet is handed out again, like in a fresh
+	// store.
+	next, err := pets.Create(ctx, model.Pet{Name: "Tom", Status: "available"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.ID != kept.ID+1 {
+		t.Errorf("got ID %d after rollback, want %d", next.ID, kept.ID+1)
+	}
+}
+
+func TestWithinTxCommits(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	tx := memory.NewTxManager(store)
+	pets := memory.NewPetRepository(store)
+
+	var created model.Pet
+	err := tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = pets.Create(ctx, model.Pet{Name: "Rex", Status: "available"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pets.FindByID(ctx, created.ID); err != nil {
+		t.Errorf("committed pet not found: %v", err)
+	}
+}
+
+func TestWritesOutsideTxWaitForTx(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	tx := memory.NewTxManager(store)
+	pets := memory.NewPetRepository(store)
+
+	inTx := make(chan struct{})
+	written := make(chan error)
+	err := tx.WithinTx(ctx, func(ctx context.Context) error {
+		go func() {
+			<-inTx
+			_, err := pets.Create(context.Background(), model.Pet{Name: "Outside", Status: "available"})
+			written <- err
+		}()
+		close(inTx)
+
+		select {
+		case <-written:
+			t.Error("write outside the transaction did not wait for it")
+		case <-time.After(50 * time.Millisecond):
+		}
+		return errors.New("roll back")
+	})
+	if err == nil {
+		t.Fatal("transaction did not fail")
+	}
+	if err := <-written; err != nil {
+		t.Fatal(err)
+	}
+
+	// The rollback happened before the outside write, which is kept.
+	all, err := pets.FindByStatus(ctx, []string{"available"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].Name != "Outside" {
+		t.Errorf("got pets %+v, want only the one written outside the transaction", all)
+	}
+}
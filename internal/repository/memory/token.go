@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"petstore/internal/model"
+	"petstore/internal/repository"
+	"time"
+)
+
+type tokenRepo struct {
+	s *Store
+}
+
+func NewTokenRepository(s *Store) repository.TokenRepository {
+	return &tokenRepo{s: s}
+}
+
+func (r *tokenRepo) Create(ctx context.Context, token model.UserToken) (model.UserToken, error) {
+	defer r.s.lock(ctx)()
+
+	if _, ok := r.s.users[token.UserID]; !ok {
+		return token, fmt.Errorf("failed to insert token: user %d does not exist", token.UserID)
+	}
+	for _, other := range r.s.tokens {
+		if other.TokenHash == token.TokenHash {
+			return token, fmt.Errorf("failed to insert token: %w", &repository.ConflictError{Field: "token"})
+		}
+	}
+
+	r.s.lastTokenID++
+	token.ID = r.s.lastTokenID
+	token.CreatedAt = time.Now()
+	r.s.tokens[token.ID] = token
+	return token, nil
+}
+
+// Consume marks a valid, unexpired token as used and returns it.
+// sql.ErrNoRows is returned for unknown, used or expired tokens.
+func (r *tokenRepo) Consume(ctx context.Context, purpose, tokenHash string) (model.UserToken, error) {
+	return r.consume(ctx, func(token model.UserToken) bool {
+		return token.TokenHash == tokenHash && token.Purpose == purpose
+	})
+}
+
+func (r *tokenRepo) ConsumeForUser(ctx context.Context, userID int64, purpose, tokenHash string) (model.UserToken, error) {
+	return r.consume(ctx, func(token model.UserToken) bool {
+		return token.TokenHash == tokenHash && token.Purpose == purpose && token.UserID == userID
+	})
+}
+
+func (r *tokenRepo) consume(ctx context.Context, match func(model.UserToken) bool) (model.UserToken, error) {
+	defer r.s.lock(ctx)()
+
+	now := time.Now()
+	for id, token := range r.s.tokens {
+		if !match(token) || token.UsedAt != nil || (token.ExpiresAt != nil && !token.ExpiresAt.After(now)) {
+			continue
+		}
+		token.UsedAt = &now
+		r.s.tokens[id] = token
+		return token, nil
+	}
+	return model.UserToken{}, fmt.Errorf("failed to consume token: %w", sql.ErrNoRows)
+}
+
+func (r *tokenRepo) DeleteByUser(ctx context.Context, userID int64, purpose string) error {
+	defer r.s.lock(ctx)()
+
+	for id, token := range r.s.tokens {
+		if token.UserID == userID && token.Purpose == purpose {
+			delete(r.s.tokens, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"petstore/internal/model"
+	"petstore/internal/repository"
+	"slices"
+	"strings"
+	"time"
+)
+
+type userRepo struct {
+	s *Store
+}
+
+func NewUserRepository(s *Store) repository.UserRepository {
+	return &userRepo{s: s}
+}
+
+func (u *userRepo) Create(ctx context.Context, user model.User) (model.User, error) {
+	defer u.s.lock(ctx)()
+
+	user, err := u.s.insertUser(user)
+	if err != nil {
+		return user, fmt.Errorf("failed to insert user: %w", err)
+	}
+	return user, nil
+}
+
+// CreateBatch inserts either every user or none.
+func (u *userRepo) CreateBatch(ctx context.Context, users []model.User) ([]model.User, error) {
+	defer u.s.lock(ctx)()
+
+	// Check the whole batch, including against itself, before inserting.
+	batch := NewStore()
+	for _, user := range users {
+		conflict := u.s.userConflict(user, 0)
+		if conflict == nil {
+			conflict = batch.userConflict(user, 0)
+		}
+		if conflict != nil {
+			conflict.Value = ""
+			return nil, fmt.Errorf("failed creating users: %w", conflict)
+		}
+		batch.insertUser(user)
+	}
+
+	created := make([]model.User, 0, len(users))
+	for _, user := range users {
+		user, err := u.s.insertUser(user)
+		if err != nil {
+			return nil, fmt.Errorf("failed creating users: %w", err)
+		}
+		created = append(created, user)
+	}
+	return created, nil
+}
+
+// CreateEach inserts users one by one. The returned slices are indexed like
+// the input; a failed row has a zero user and a non-nil error.
+func (u *userRepo) CreateEach(ctx context.Context, users []model.User) ([]model.User, []error, error) {
+	defer u.s.lock(ctx)()
+
+	created := make([]model.User, len(users))
+	errs := make([]error, len(users))
+	for i, user := range users {
+		user, err := u.s.insertUser(user)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to insert user: %w", err)
+			continue
+		}
+		created[i] = user
+	}
+	return created, errs, nil
+}
+
+// ExistingUsernames returns which of the usernames are taken. Keys are
+// lower-cased, as usernames are unique regardless of case.
+func (u *userRepo) ExistingUsernames(_ context.Context, usernames []string) (map[string]bool, error) {
+	u.s.mu.RLock()
+	defer u.s.mu.RUnlock()
+
+	taken := make(map[string]bool, len(u.s.users))
+	for _, user := range u.s.users {
+		taken[strings.ToLower(user.Username)] = true
+	}
+
+	existing := make(map[string]bool)
+	for _, name := range usernames {
+		if name = strings.ToLower(name); taken[name] {
+			existing[name] = true
+		}
+	}
+	return existing, nil
+}
+
+// ExistingEmails returns which of the emails are taken. Keys are lower-cased,
+// as emails are unique regardless of case.
+func (u *userRepo) ExistingEmails(_ context.Context, emails []string) (map[string]bool, error) {
+	u.s.mu.RLock()
+	defer u.s.mu.RUnlock()
+
+	taken := make(map[string]bool, len(u.s.users))
+	for _, user := range u.s.users {
+		if user.Email != "" {
+			taken[strings.ToLower(user.Email)] = true
+		}
+	}
+
+	existing := make(map[string]bool)
+	for _, email := range emails {
+		if email = strings.ToLower(email); taken[email] {
+			existing[email] = true
+		}
+	}
+	return existing, nil
+}
+
+func (u *userRepo) FindByUsername(_ context.Context, username string) (model.User, error) {
+	u.s.mu.RLock()
+	defer u.s.mu.RUnlock()
+
+	user, ok := u.s.userByUsername(username)
+	if !ok {
+		return model.User{}, notFound("user %s not found", username)
+	}
+	return user, nil
+}
+
+func (u *userRepo) FindByID(_ context.Context, id int64) (model.User, error) {
+	u.s.mu.RLock()
+	defer u.s.mu.RUnlock()
+
+	user, ok := u.s.users[id]
+	if !ok {
+		return model.User{}, notFound("user %d not found", id)
+	}
+	return user, nil
+}
+
+func (u *userRepo) FindByEmail(_ context.Context, email string) (model.User, error) {
+	return u.find(func(user model.User) bool {
+		return user.Email != "" && strings.EqualFold(user.Email, email)
+	})
+}
+
+func (u *userRepo) FindByExternalIdentity(_ context.Context, provider, subject string) (model.User, error) {
+	return u.find(func(user model.User) bool {
+		return user.AuthProvider != "" && user.AuthProvider == provider && user.ExternalSubject == subject
+	})
+}
+
+func (u *userRepo) find(match func(model.User) bool) (model.User, error) {
+	u.s.mu.RLock()
+	defer u.s.mu.RUnlock()
+
+	for _, user := range u.s.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+	return model.User{}, notFound("user not found")
+}
+
+// Search returns one page of users matching the filter, ordered by username,
+// together with the total number of matches.
+func (u *userRepo) Search(_ context.Context, search model.UserSearch) ([]model.User, int, error) {
+	u.s.mu.RLock()
+	defer u.s.mu.RUnlock()
+
+	query := strings.ToLower(search.Query)
+	matches := []model.User{}
+	for _, user := range u.s.users {
+		if search.Status != nil && user.UserStatus != *search.Status {
+			continue
+		}
+		if query != "" &&
+			!strings.Contains(strings.ToLower(user.Username), query) &&
+			!strings.Contains(strings.ToLower(user.Email), query) &&
+			!strings.Contains(strings.ToLower(user.FirstName+" "+user.LastName), query) {
+			continue
+		}
+		matches = append(matches, user)
+	}
+	slices.SortFunc(matches, func(a, b model.User) int {
+		return cmp.Or(
+			strings.Compare(strings.ToLower(a.Username), strings.ToLower(b.Username)),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+
+	total := len(matches)
+	start := min(max(search.Offset, 0), total)
+	end := min(start+max(search.Limit, 0), total)
+	return matches[start:end], total, nil
+}
+
+func (u *userRepo) Update(ctx context.Context, username string, user model.User) (model.User, error) {
+	unlock := u.s.lock(ctx)
+	stored, ok := u.s.userByUsername(username)
+	if ok {
+		if conflict := u.s.userConflict(model.User{Email: user.Email}, stored.ID); conflict != nil {
+			unlock()
+			return model.User{}, fmt.Errorf("failed to update user: %w", conflict)
+		}
+		stored.FirstName = user.FirstName
+		stored.LastName = user.LastName
+		stored.Email = user.Email
+		stored.Password = user.Password
+		stored.Phone = user.Phone
+		stored.UserStatus = user.UserStatus
+		u.s.users[stored.ID] = stored
+	}
+	unlock()
+
+	updatedUser, err := u.FindByUsername(ctx, username)
+	if err != nil {
+		return model.User{}, fmt.Errorf("failed to retrieve updated user: %w", err)
+	}
+	return updatedUser, nil
+}
+
+func (u *userRepo) UpdatePassword(ctx context.Context, id int64, passwordHash string) error {
+	u.s.updateUser(ctx, id, func(user *model.User) { user.Password = passwordHash })
+	return nil
+}
+
+func (u *userRepo) RevokeAccessTokens(ctx context.Context, id int64) error {
+	u.s.updateUser(ctx, id, func(user *model.User) { user.TokenVersion++ })
+	return nil
+}
+
+func (u *userRepo) UpdateStatus(ctx context.Context, id int64, status int) error {
+	u.s.updateUser(ctx, id, func(user *model.User) { user.UserStatus = status })
+	return nil
+}
+
+func (u *userRepo) UpdateLastLogin(ctx context.Context, id int64) error {
+	now := time.Now()
+	u.s.updateUser(ctx, id, func(user *model.User) { user.LastLoginAt = &now })
+	return nil
+}
+
+func (u *userRepo) UpdateTOTP(ctx context.Context, id int64, secret string, enabled bool) error {
+	u.s.updateUser(ctx, id, func(user *model.User) {
+		user.TOTPSecret = secret
+		user.TOTPEnabled = enabled
+	})
+	return nil
+}
+
+// UseTOTPStep records step as the last accepted TOTP time step of the user.
+// It reports false if the same or a later step was accepted before.
+func (u *userRepo) UseTOTPStep(ctx context.Context, id int64, step int64) (bool, error) {
+	defer u.s.lock(ctx)()
+
+	if _, ok := u.s.users[id]; !ok {
+		return false, nil
+	}
+	if last, ok := u.s.totpSteps[id]; ok && last >= step {
+		return false, nil
+	}
+	u.s.totpSteps[id] = step
+	return true, nil
+}
+
+// UpdateExternalIdentity links the user to the identity provider subject and
+// refreshes the fields the provider is authoritative for.
+func (u *userRepo) UpdateExternalIdentity(ctx context.Context, id int64, identity model.ExternalIdentity) error {
+	defer u.s.lock(ctx)()
+
+	user, ok := u.s.users[id]
+	if !ok {
+		return nil
+	}
+	check := model.User{Email: identity.Email, AuthProvider: identity.Provider, ExternalSubject: identity.Subject}
+	if conflict := u.s.userConflict(check, id); conflict != nil {
+		return fmt.Errorf("failed to update external identity: %w", conflict)
+	}
+	user.AuthProvider = identity.Provider
+	user.ExternalSubject = identity.Subject
+	user.FirstName = identity.FirstName
+	user.LastName = identity.LastName
+	user.Email = identity.Email
+	user.Role = identity.Role
+	u.s.users[id] = user
+	return nil
+}
+
+// Delete removes the user together with the tokens, API keys and erasure
+// requests, and unlinks the orders, as the foreign keys do.
+func (u *userRepo) Delete(ctx context.Context, username string) error {
+	defer u.s.lock(ctx)()
+
+	user, ok := u.s.userByUsername(username)
+	if !ok {
+		return notFound("user %s not found", username)
+	}
+	delete(u.s.users, user.ID)
+	delete(u.s.totpSteps, user.ID)
+	u.s.deleteCredentials(user.ID)
+	for id, req := range u.s.erasures {
+		if req.UserID == user.ID {
+			delete(u.s.erasures, id)
+		}
+	}
+	for id, order := range u.s.orders {
+		if order.UserID != nil && *order.UserID == user.ID {
+			order.UserID = nil
+			u.s.orders[id] = order
+		}
+	}
+	return nil
+}
+
+// insertUser stores a new user. The caller holds the write lock.
+func (s *Store) insertUser(user model.User) (model.User, error) {
+	if conflict := s.userConflict(user, 0); conflict != nil {
+		return user, conflict
+	}
+	if user.Role == "" {
+		user.Role = model.RoleCustomer
+	}
+	s.lastUserID++
+	user.ID = s.lastUserID
+	s.users[user.ID] = user
+	return user, nil
+}
+
+// userConflict reports which unique field of user is already taken by
+// another user than exceptID. Reserved usernames count as taken, like the
+// check constraint of the users table.
+func (s *Store) userConflict(user model.User, exceptID int64) *repository.ConflictError {
+	if model.IsReservedUsername(user.Username) && user.UserStatus != model.UserStatusErased {
+		return &repository.ConflictError{Field: "username", Value: user.Username}
+	}
+	for _, other := range s.users {
+		switch {
+		case other.ID == exceptID:
+		case user.Username != "" && strings.EqualFold(other.Username, user.Username):
+			return &repository.ConflictError{Field: "username", Value: user.Username}
+		case user.Email != "" && strings.EqualFold(other.Email, user.Email):
+			return &repository.ConflictError{Field: "email", Value: user.Email}
+		case user.AuthProvider != "" && other.AuthProvider == user.AuthProvider &&
+			other.ExternalSubject == user.ExternalSubject:
+			return &repository.ConflictError{Field: "external identity"}
+		}
+	}
+	return nil
+}
+
+func (s *Store) userByUsername(username string) (model.User, bool) {
+	for _, user := range s.users {
+		if strings.EqualFold(user.Username, username) {
+			return user, true
+		}
+	}
+	return model.User{}, false
+}
+
+// updateUser applies update to the user with the given ID, if there is one.
+func (s *Store) updateUser(ctx context.Context, id int64, update func(*model.User)) {
+	defer s.lock(ctx)()
+
+	if user, ok := s.users[id]; ok {
+		update(&user)
+		s.users[id] = user
+	}
+}
+
+// deleteCredentials removes the tokens and API keys of a user. The caller
+// holds the write lock.
+func (s *Store) deleteCredentials(userID int64) {
+	for id, token := range s.tokens {
+		if token.UserID == userID {
+			delete(s.tokens, id)
+		}
+	}
+	for id, key := range s.apiKeys {
+		if key.UserID == userID {
+			delete(s.apiKeys, id)
+		}
+	}
+}
@@ -2,9 +2,10 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"petstore/internal/apperror"
 	"petstore/internal/model"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -12,32 +13,38 @@ import (
 type OrderRepository interface {
 	Create(ctx context.Context, order model.Order) (model.Order, error)
 	FindByID(ctx context.Context, orderID int) (model.Order, error)
+	ListByUser(ctx context.Context, userID int64) ([]model.Order, error)
 	Delete(ctx context.Context, orderID int) error
 	GetInventory(ctx context.Context) (map[string]int, error)
 }
 
 type orderRepo struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	timeout time.Duration
 }
 
-func NewOrderRepository(db *sqlx.DB) OrderRepository {
-	return &orderRepo{db: db}
+func NewOrderRepository(db *sqlx.DB, queryTimeout time.Duration) OrderRepository {
+	return &orderRepo{db: db, timeout: queryTimeout}
 }
 
 func (r *orderRepo) Create(ctx context.Context, order model.Order) (model.Order, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		INSERT INTO orders (pet_id, quantity, ship_date, status, complete)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO orders (pet_id, quantity, ship_date, status, complete, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id;
 	`
 
 	var newID int
-	err := r.db.QueryRowContext(ctx, query,
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
 		order.PetID,
 		order.Quantity,
 		order.ShipDate,
 		order.Status,
 		order.Complete,
+		order.UserID,
 	).Scan(&newID)
 
 	if err != nil {
@@ -49,40 +56,70 @@ func (r *orderRepo) Create(ctx context.Context, order model.Order) (model.Order,
 }
 
 func (r *orderRepo) FindByID(ctx context.Context, orderID int) (model.Order, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		SELECT id, pet_id, quantity, ship_date, status, complete
+		SELECT id, pet_id, quantity, ship_date, status, complete, user_id
 		FROM orders WHERE id = $1
 	`
 	var order model.Order
 
-	err := r.db.GetContext(ctx, &order, query, orderID)
+	err := conn(ctx, r.db).GetContext(ctx, &order, query, orderID)
 	if err != nil {
-		return order, fmt.Errorf("failed to find order by id: %w", err)
+		return order, findError(err, apperror.NotFound("order with ID %d not found", orderID), "find order by id")
 	}
 
 	return order, nil
 }
 
-func (r *orderRepo) Delete(ctx context.Context, orderID int) error {
-	query := `DELETE FROM orders WHERE id = $1`
+func (r *orderRepo) ListByUser(ctx context.Context, userID int64) ([]model.Order, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 
-	status, err := r.GetStatusByID(ctx, orderID)
+	query := `
+		SELECT id, pet_id, quantity, ship_date, status, complete, user_id
+		FROM orders WHERE user_id = $1
+		ORDER BY id
+	`
+	orders := []model.Order{}
+
+	err := conn(ctx, r.db).SelectContext(ctx, &orders, query, userID)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to list orders by user: %w", err)
 	}
 
-	if status == "delivered" {
-		return fmt.Errorf("cannot delete a completed order")
-	}
+	return orders, nil
+}
 
-	_, err = r.db.ExecContext(ctx, query, orderID)
-	if err != nil {
-		return fmt.Errorf("failed to delete order: %w", err)
-	}
-	return nil
+func (r *orderRepo) Delete(ctx context.Context, orderID int) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `DELETE FROM orders WHERE id = $1`
+
+	return inTx(ctx, r.db, func(ctx context.Context) error {
+		status, err := r.GetStatusByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if status == "delivered" {
+			return apperror.Conflict("cannot delete a completed order")
+		}
+
+		_, err = conn(ctx, r.db).ExecContext(ctx, query, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to delete order: %w", err)
+		}
+		return nil
+	})
 }
 
 func (r *orderRepo) GetInventory(ctx context.Context) (map[string]int, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		SELECT status, COUNT(*) as count
 		FROM orders
@@ -95,7 +132,7 @@ func (r *orderRepo) GetInventory(ctx context.Context) (map[string]int, error) {
 	}
 
 	var res []statusCount
-	err := r.db.SelectContext(ctx, &res, query)
+	err := conn(ctx, r.db).SelectContext(ctx, &res, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get inventory: %w", err)
 	}
@@ -107,11 +144,16 @@ func (r *orderRepo) GetInventory(ctx context.Context) (map[string]int, error) {
 	return inventory, nil
 }
 
+// GetStatusByID locks the order until the end of the transaction of ctx, so
+// that the status can't change before the caller acts on it.
 func (r *orderRepo) GetStatusByID(ctx context.Context, orderID int) (string, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var status string
-	err := r.db.QueryRowContext(ctx, `SELECT status FROM orders WHERE id = $1`, orderID).Scan(&status)
-	if err == sql.ErrNoRows {
-		return "", fmt.Errorf("order not found")
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, orderID).Scan(&status)
+	if err != nil {
+		return "", findError(err, apperror.NotFound("order with ID %d not found", orderID), "get order status")
 	}
 	return status, nil
 }
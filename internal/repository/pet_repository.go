@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"petstore/internal/apperror"
 	"petstore/internal/model"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
@@ -22,14 +24,18 @@ type PetRepository interface {
 }
 
 type petRepo struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	timeout time.Duration
 }
 
-func NewPetRepository(db *sqlx.DB) PetRepository {
-	return &petRepo{db: db}
+func NewPetRepository(db *sqlx.DB, queryTimeout time.Duration) PetRepository {
+	return &petRepo{db: db, timeout: queryTimeout}
 }
 
 func (r *petRepo) Create(ctx context.Context, pet model.Pet) (model.Pet, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	petDB, err := model.PetToPetDB(pet)
 	if err != nil {
 		return pet, fmt.Errorf("failed to convert pet to db: %w", err)
@@ -41,7 +47,7 @@ func (r *petRepo) Create(ctx context.Context, pet model.Pet) (model.Pet, error)
 		RETURNING id;
 	`
 	var newID int
-	err = r.db.QueryRowContext(ctx, query,
+	err = conn(ctx, r.db).QueryRowContext(ctx, query,
 		petDB.Name,
 		petDB.Status,
 		petDB.Category,
@@ -57,6 +63,9 @@ func (r *petRepo) Create(ctx context.Context, pet model.Pet) (model.Pet, error)
 }
 
 func (r *petRepo) Update(ctx context.Context, pet model.Pet) (model.Pet, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	petDB, err := model.PetToPetDB(pet)
 	if err != nil {
 		return pet, fmt.Errorf("failed to convert pet to db: %w", err)
@@ -67,7 +76,7 @@ func (r *petRepo) Update(ctx context.Context, pet model.Pet) (model.Pet, error)
 		SET name=$1, status=$2, category=$3, photo_urls=$4, tags=$5
 		WHERE id=$6
 	`
-	_, err = r.db.ExecContext(ctx, query,
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
 		petDB.Name,
 		petDB.Status,
 		petDB.Category,
@@ -83,12 +92,15 @@ func (r *petRepo) Update(ctx context.Context, pet model.Pet) (model.Pet, error)
 }
 
 func (r *petRepo) UpdateFormData(ctx context.Context, petID int, name, status string) (model.Pet, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		UPDATE pets
 		SET name=$1, status=$2
 		WHERE id = $3
 	`
-	_, err := r.db.ExecContext(ctx, query, name, status, petID)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, name, status, petID)
 	if err != nil {
 		return model.Pet{}, fmt.Errorf("failed to update pet form data: %w", err)
 	}
@@ -102,20 +114,26 @@ func (r *petRepo) UpdateFormData(ctx context.Context, petID int, name, status st
 }
 
 func (r *petRepo) FindByID(ctx context.Context, petID int) (model.Pet, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		SELECT id, name, status, category, photo_urls, tags
 		FROM pets WHERE id = $1
 	`
 	var petDB model.PetDB
-	err := r.db.GetContext(ctx, &petDB, query, petID)
+	err := conn(ctx, r.db).GetContext(ctx, &petDB, query, petID)
 	if err != nil {
-		return model.Pet{}, fmt.Errorf("failed to find pet by id: %w", err)
+		return model.Pet{}, findError(err, apperror.NotFound("pet with ID %d not found", petID), "find pet by id")
 	}
 
 	return model.PetDBToPet(petDB)
 }
 
 func (r *petRepo) FindByStatus(ctx context.Context, statuses []string) ([]model.Pet, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		SELECT id, name, status, category, photo_urls, tags
 		FROM pets
@@ -123,7 +141,7 @@ func (r *petRepo) FindByStatus(ctx context.Context, statuses []string) ([]model.
 	`
 
 	var petDBs []model.PetDB
-	err := r.db.SelectContext(ctx, &petDBs, query, pq.Array(statuses))
+	err := conn(ctx, r.db).SelectContext(ctx, &petDBs, query, pq.Array(statuses))
 	if err != nil {
 		return nil, fmt.Errorf("failed to select pets by status: %w", err)
 	}
@@ -141,12 +159,15 @@ func (r *petRepo) FindByStatus(ctx context.Context, statuses []string) ([]model.
 }
 
 func (r *petRepo) FindByTags(ctx context.Context, tags []string) ([]model.Pet, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		SELECT id, name, status, category, photo_urls, tags
 		FROM pets
 	`
 	var petDBs []model.PetDB
-	err := r.db.SelectContext(ctx, &petDBs, query)
+	err := conn(ctx, r.db).SelectContext(ctx, &petDBs, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to select pets: %w", err)
 	}
@@ -168,20 +189,29 @@ func (r *petRepo) FindByTags(ctx context.Context, tags []string) ([]model.Pet, e
 }
 
 func (r *petRepo) Delete(ctx context.Context, petID int) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `DELETE FROM pets WHERE id = $1`
 
-	_, err := r.db.ExecContext(ctx, query, petID)
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, petID)
 	if err != nil {
 		return fmt.Errorf("failed to delete pet: %w", err)
 	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return apperror.NotFound("pet with ID %d not found", petID)
+	}
 	return nil
 }
 
 func (r *petRepo) ExistsByID(ctx context.Context, petID int) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `SELECT 1 FROM pets WHERE id = $1 LIMIT 1`
 
 	var dummy int
-	err := r.db.QueryRowContext(ctx, query, petID).Scan(&dummy)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, petID).Scan(&dummy)
 	if err == sql.ErrNoRows {
 		return false, nil
 	}
@@ -0,0 +1,233 @@
+// Package repotest holds the behaviour every implementation of the
+// repositories must share, so that the memory repositories stay a faithful
+// stand-in for the Postgres ones.
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"petstore/internal/apperror"
+	"petstore/internal/model"
+	"petstore/internal/repository"
+	"testing"
+	"time"
+)
+
+type Repositories struct {
+	Pets   repository.PetRepository
+	Orders repository.OrderRepository
+	Users  repository.UserRepository
+}
+
+// Run checks the repositories returned by newRepos against the contract.
+// Every call of newRepos must return empty repositories that share no data
+// with earlier ones.
+func Run(t *testing.T, newRepos func(t *testing.T) Repositories) {
+	t.Run("IDs", func(t *testing.T) { testIDs(t, newRepos(t)) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, newRepos(t)) })
+	t.Run("DeliveredOrder", func(t *testing.T) { testDeliveredOrder(t, newRepos(t)) })
+	t.Run("UsernameCase", func(t *testing.T) { testUsernameCase(t, newRepos(t)) })
+	t.Run("ReservedUsername", func(t *testing.T) { testReservedUsername(t, newRepos(t)) })
+}
+
+func testIDs(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	var petIDs []int
+	for _, name := range []string{"Rex", "Tom", "Kit"} {
+		pet, err := r.Pets.Create(ctx, model.Pet{Name: name, Status: "available"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		petIDs = append(petIDs, pet.ID)
+
+		found, err := r.Pets.FindByID(ctx, pet.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Name != name {
+			t.Errorf("pet %d is called %q, want %q", pet.ID, found.Name, name)
+		}
+	}
+	checkIncreasing(t, "pet", petIDs)
+
+	var orderIDs []int
+	for range 2 {
+		order, err := r.Orders.Create(ctx, newOrder(petIDs[0], "placed"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		orderIDs = append(orderIDs, order.ID)
+	}
+	checkIncreasing(t, "order", orderIDs)
+
+	var userIDs []int
+	for _, name := range []string{"jane", "john"} {
+		user, err := r.Users.Create(ctx, model.User{Username: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		userIDs = append(userIDs, int(user.ID))
+
+		found, err := r.Users.FindByID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Username != name || found.Role != model.RoleCustomer {
+			t.Errorf("user %d = %q with role %q, want %q with role %q",
+				user.ID, found.Username, found.Role, name, model.RoleCustomer)
+		}
+	}
+	checkIncreasing(t, "user", userIDs)
+}
+
+func checkIncreasing(t *testing.T, kind string, ids []int) {
+	t.Helper()
+	for i, id := range ids {
+		if id <= 0 || i > 0 && id <= ids[i-1] {
+			t.Errorf("got %s IDs %v, want positive and increasing", kind, ids)
+			return
+		}
+	}
+}
+
+func testNotFound(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	finds := map[string]func() error{
+		"Pets.FindByID": func() error {
+			_, err := r.Pets.FindByID(ctx, 999)
+			return err
+		},
+		"Orders.FindByID": func() error {
+			_, err := r.Orders.FindByID(ctx, 999)
+			return err
+		},
+		"Users.FindByID": func() error {
+			_, err := r.Users.FindByID(ctx, 999)
+			return err
+		},
+		"Users.FindByUsername": func() error {
+			_, err := r.Users.FindByUsername(ctx, "nobody")
+			return err
+		},
+	}
+	for name, find := range finds {
+		err := find()
+		if apperror.KindOf(err) != apperror.KindNotFound {
+			t.Errorf("%s: got %v, want a not found error", name, err)
+		}
+		// Services tell a missing row from a failure this way.
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("%s: got %v, want it to wrap sql.ErrNoRows", name, err)
+		}
+	}
+
+	deletes := map[string]func() error{
+		"Pets.Delete":   func() error { return r.Pets.Delete(ctx, 999) },
+		"Orders.Delete": func() error { return r.Orders.Delete(ctx, 999) },
+		"Users.Delete":  func() error { return r.Users.Delete(ctx, "nobody") },
+	}
+	for name, del := range deletes {
+		if err := del(); apperror.KindOf(err) != apperror.KindNotFound {
+			t.Errorf("%s: got %v, want a not found error", name, err)
+		}
+	}
+}
+
+func testDeliveredOrder(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	pet, err := r.Pets.Create(ctx, model.Pet{Name: "Rex", Status: "available"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivered, err := r.Orders.Create(ctx, newOrder(pet.ID, "delivered"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	placed, err := r.Orders.Create(ctx, newOrder(pet.ID, "placed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Orders.Delete(ctx, delivered.ID); apperror.KindOf(err) != apperror.KindConflict {
+		t.Errorf("deleting a delivered order: got %v, want a conflict", err)
+	}
+	if _, err := r.Orders.FindByID(ctx, delivered.ID); err != nil {
+		t.Errorf("delivered order is gone: %v", err)
+	}
+
+	if err := r.Orders.Delete(ctx, placed.ID); err != nil {
+		t.Fatalf("deleting a placed order: %v", err)
+	}
+	if _, err := r.Orders.FindByID(ctx, placed.ID); apperror.KindOf(err) != apperror.KindNotFound {
+		t.Errorf("deleted order: got %v, want a not found error", err)
+	}
+}
+
+func testUsernameCase(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	jane, err := r.Users.Create(ctx, model.User{Username: "Jane"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"jane", "JANE", "Jane"} {
+		found, err := r.Users.FindByUsername(ctx, name)
+		if err != nil {
+			t.Errorf("FindByUsername(%q): %v", name, err)
+		} else if found.ID != jane.ID {
+			t.Errorf("FindByUsername(%q) = user %d, want %d", name, found.ID, jane.ID)
+		}
+	}
+
+	_, err = r.Users.Create(ctx, model.User{Username: "JANE"})
+	var conflict *repository.ConflictError
+	if !errors.As(err, &conflict) || conflict.Field != "username" {
+		t.Errorf("creating JANE next to Jane: got %v, want a username conflict", err)
+	}
+
+	existing, err := r.Users.ExistingUsernames(ctx, []string{"jAnE", "john"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(existing) != 1 || !existing["jane"] {
+		t.Errorf("ExistingUsernames = %v, want only jane", existing)
+	}
+
+	if err := r.Users.Delete(ctx, "JANE"); err != nil {
+		t.Fatalf("Delete(JANE): %v", err)
+	}
+	if _, err := r.Users.FindByUsername(ctx, "Jane"); apperror.KindOf(err) != apperror.KindNotFound {
+		t.Errorf("deleted user: got %v, want a not found error", err)
+	}
+}
+
+// testReservedUsername checks that names given to erased users can't be
+// taken by anyone else, so that erasing a user never collides.
+func testReservedUsername(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	_, err := r.Users.Create(ctx, model.User{Username: "Erased-7"})
+	var conflict *repository.ConflictError
+	if !errors.As(err, &conflict) || conflict.Field != "username" {
+		t.Errorf("creating Erased-7: got %v, want a username conflict", err)
+	}
+
+	_, err = r.Users.Create(ctx, model.User{Username: "erased-8", UserStatus: model.UserStatusErased})
+	if err != nil {
+		t.Errorf("creating an erased user: %v", err)
+	}
+}
+
+func newOrder(petID int, status string) model.Order {
+	return model.Order{
+		PetID:    petID,
+		Quantity: 1,
+		ShipDate: time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second),
+		Status:   status,
+	}
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"petstore/internal/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TokenRepository interface {
+	Create(ctx context.Context, token model.UserToken) (model.UserToken, error)
+	Consume(ctx context.Context, purpose, tokenHash string) (model.UserToken, error)
+	ConsumeForUser(ctx context.Context, userID int64, purpose, tokenHash string) (model.UserToken, error)
+	DeleteByUser(ctx context.Context, userID int64, purpose string) error
+}
+
+type tokenRepo struct {
+	db      *sqlx.DB
+	timeout time.Duration
+}
+
+func NewTokenRepository(db *sqlx.DB, queryTimeout time.Duration) TokenRepository {
+	return &tokenRepo{db: db, timeout: queryTimeout}
+}
+
+func (r *tokenRepo) Create(ctx context.Context, token model.UserToken) (model.UserToken, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		token.UserID,
+		token.Purpose,
+		token.TokenHash,
+		token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return token, fmt.Errorf("failed to insert token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Consume marks a valid, unexpired token as used and returns it. The update is
+// a single statement, so a token can be consumed only once even under
+// concurrent requests. sql.ErrNoRows is returned for unknown, used or expired
+// tokens.
+func (r *tokenRepo) Consume(ctx context.Context, purpose, tokenHash string) (model.UserToken, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at
+	`
+
+	var token model.UserToken
+	err := conn(ctx, r.db).GetContext(ctx, &token, query, tokenHash, purpose)
+	if err != nil {
+		return token, fmt.Errorf("failed to consume token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *tokenRepo) ConsumeForUser(ctx context.Context, userID int64, purpose, tokenHash string) (model.UserToken, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND user_id = $3 AND used_at IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at
+	`
+
+	var token model.UserToken
+	err := conn(ctx, r.db).GetContext(ctx, &token, query, tokenHash, purpose, userID)
+	if err != nil {
+		return token, fmt.Errorf("failed to consume token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *tokenRepo) DeleteByUser(ctx context.Context, userID int64, purpose string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, userID, purpose)
+	if err != nil {
+		return fmt.Errorf("failed to delete tokens: %w", err)
+	}
+	return nil
+}
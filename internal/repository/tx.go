@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"petstore/internal/logging"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// TxManager runs functions in a database transaction. Repository calls made
+// with the context passed to fn take part in it. Calls nest: an inner call
+// runs in a savepoint, so its failure only undoes its own work.
+//
+// A transaction aborted by a serialization failure or a deadlock is run
+// again, so fn must not have side effects outside the database. The
+// transaction is bound to one connection; fn must not use it concurrently.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+const (
+	pqSerializationFailure = "40001"
+	pqDeadlockDetected     = "40P01"
+)
+
+type txKey struct{}
+
+// txState is the transaction a context belongs to.
+type txState struct {
+	tx         *sqlx.Tx
+	savepoints int
+}
+
+type txManager struct {
+	db          *sqlx.DB
+	isolation   sql.IsolationLevel
+	maxAttempts int
+}
+
+// NewTxManager returns a TxManager running transactions at the given
+// isolation level, at most maxAttempts times each.
+func NewTxManager(db *sqlx.DB, isolation sql.IsolationLevel, maxAttempts int) TxManager {
+	return &txManager{db: db, isolation: isolation, maxAttempts: max(maxAttempts, 1)}
+}
+
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if txFromContext(ctx) != nil {
+		return withSavepoint(ctx, fn)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, m.db, &sql.TxOptions{Isolation: m.isolation}, fn)
+		if err == nil || !isRetryable(err) || attempt >= m.maxAttempts {
+			return err
+		}
+
+		delay := time.Duration(rand.Int64N(int64(5*time.Millisecond) << attempt))
+		logging.FromContext(ctx).Warn("transaction aborted, retrying",
+			"attempt", attempt, "max_attempts", m.maxAttempts, "retry_in", delay.String(), "err", err)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// inTx runs fn in the transaction of ctx, using a savepoint, or else in a new
+// transaction. Repositories use it for writes that must be atomic on their
+// own; unlike WithinTx it doesn't retry.
+func inTx(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if txFromContext(ctx) != nil {
+		return withSavepoint(ctx, fn)
+	}
+	return runTx(ctx, db, nil, fn)
+}
+
+func runTx(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	tx, err := db.BeginTxx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func withSavepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	state := txFromContext(ctx)
+	state.savepoints++
+	name := fmt.Sprintf("sp_%d", state.savepoints)
+
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+	if err := fn(ctx); err != nil {
+		if _, rbErr := state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to roll back savepoint: %w", rbErr))
+		}
+		return err
+	}
+	if _, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
+
+func txFromContext(ctx context.Context) *txState {
+	state, _ := ctx.Value(txKey{}).(*txState)
+	return state
+}
+
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && (pqErr.Code == pqSerializationFailure || pqErr.Code == pqDeadlockDetected)
+}
+
+// querier is what repositories need of *sqlx.DB and *sqlx.Tx.
+type querier interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction of ctx, or db outside of one.
+func conn(ctx context.Context, db *sqlx.DB) querier {
+	if state := txFromContext(ctx); state != nil {
+		return state.tx
+	}
+	return db
+}
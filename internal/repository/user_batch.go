@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"petstore/internal/logging"
+	"petstore/internal/model"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	// Batches above this size are loaded with COPY instead of INSERT.
+	userCopyThreshold = 1000
+	// Rows per multi-row INSERT, well below the 65535 bind parameter limit.
+	userInsertChunk = 500
+)
+
+// CreateBatch inserts all users in one transaction: either every user is
+// created or none is.
+func (u *userRepo) CreateBatch(ctx context.Context, users []model.User) ([]model.User, error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	var created []model.User
+	err := inTx(ctx, u.db, func(ctx context.Context) error {
+		tx := txFromContext(ctx).tx
+
+		var err error
+		if len(users) > userCopyThreshold {
+			created, err = copyUsers(ctx, tx, users)
+		} else {
+			created, err = insertUsers(ctx, tx, users)
+		}
+		if err != nil {
+			return fmt.Errorf("failed creating users: %w", mapUniqueViolation(err, nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Debug("batch users created", "count", len(created))
+	return created, nil
+}
+
+// CreateEach inserts users one by one inside a transaction, isolating each
+// row with a savepoint so that a failing row does not abort the others. The
+// returned slices are indexed like the input; a failed row has a zero user
+// and a non-nil error.
+func (u *userRepo) CreateEach(ctx context.Context, users []model.User) ([]model.User, []error, error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	created := make([]model.User, len(users))
+	errs := make([]error, len(users))
+	err := inTx(ctx, u.db, func(ctx context.Context) error {
+		q := conn(ctx, u.db)
+		for i, user := range users {
+			err := inTx(ctx, u.db, func(ctx context.Context) error {
+				created[i], errs[i] = insertUser(ctx, q, user)
+				return errs[i]
+			})
+			if err != nil && err != errs[i] {
+				// The savepoint itself failed, the transaction is unusable.
+				return err
+			}
+			if errs[i] != nil {
+				logging.FromContext(ctx).Debug("batch user insert rolled back", "index", i, "username", user.Username, "err", errs[i])
+				created[i] = model.User{}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, errs, nil
+}
+
+// ExistingUsernames returns which of the usernames are taken. Keys are
+// lower-cased, as usernames are unique regardless of case.
+func (u *userRepo) ExistingUsernames(ctx context.Context, usernames []string) (map[string]bool, error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	lowered := make([]string, 0, len(usernames))
+	for _, name := range usernames {
+		lowered = append(lowered, strings.ToLower(name))
+	}
+
+	query := `SELECT LOWER(username) FROM users WHERE LOWER(username) = ANY($1)`
+
+	var found []string
+	err := conn(ctx, u.db).SelectContext(ctx, &found, query, pq.Array(lowered))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check usernames: %w", err)
+	}
+
+	existing := make(map[string]bool, len(found))
+	for _, name := range found {
+		existing[name] = true
+	}
+	return existing, nil
+}
+
+// ExistingEmails returns which of the emails are taken. Keys are lower-cased,
+// as emails are unique regardless of case.
+func (u *userRepo) ExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	lowered := make([]string, 0, len(emails))
+	for _, email := range emails {
+		lowered = append(lowered, strings.ToLower(email))
+	}
+
+	query := `SELECT LOWER(email) FROM users WHERE email <> '' AND LOWER(email) = ANY($1)`
+
+	var found []string
+	err := conn(ctx, u.db).SelectContext(ctx, &found, query, pq.Array(lowered))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check emails: %w", err)
+	}
+
+	existing := make(map[string]bool, len(found))
+	for _, email := range found {
+		existing[email] = true
+	}
+	return existing, nil
+}
+
+func insertUsers(ctx context.Context, tx *sqlx.Tx, users []model.User) ([]model.User, error) {
+	created := make([]model.User, 0, len(users))
+
+	for start := 0; start < len(users); start += userInsertChunk {
+		end := start + userInsertChunk
+		if end > len(users) {
+			end = len(users)
+		}
+		chunk := users[start:end]
+
+		var b strings.Builder
+		b.WriteString(`INSERT INTO users (username, first_name, last_name, email, password, phone, user_status,
+			role, auth_provider, external_subject) VALUES `)
+		args := make([]interface{}, 0, len(chunk)*10)
+		for i, user := range chunk {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			n := len(args)
+			fmt.Fprintf(&b, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, COALESCE(NULLIF($%d, ''), 'customer'), NULLIF($%d, ''), NULLIF($%d, ''))",
+				n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10)
+			args = append(args, userInsertArgs(user)...)
+		}
+		b.WriteString(` RETURNING id`)
+
+		// Postgres returns the RETURNING rows of a multi-row VALUES insert in
+		// input order.
+		var ids []int64
+		if err := tx.SelectContext(ctx, &ids, b.String(), args...); err != nil {
+			return nil, err
+		}
+		if len(ids) != len(chunk) {
+			return nil, fmt.Errorf("inserted %d users, expected %d", len(ids), len(chunk))
+		}
+		for i, user := range chunk {
+			user.ID = ids[i]
+			created = append(created, user)
+		}
+	}
+
+	return created, nil
+}
+
+func copyUsers(ctx context.Context, tx *sqlx.Tx, users []model.User) ([]model.User, error) {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("users",
+		"username", "first_name", "last_name", "email", "password", "phone", "user_status", "role"))
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := make([]string, 0, len(users))
+	for _, user := range users {
+		role := user.Role
+		if role == "" {
+			role = model.RoleCustomer
+		}
+		_, err := stmt.ExecContext(ctx, user.Username, user.FirstName, user.LastName, user.Email,
+			user.Password, user.Phone, user.UserStatus, role)
+		if err != nil {
+			stmt.Close()
+			return nil, err
+		}
+		usernames = append(usernames, user.Username)
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return nil, err
+	}
+	if err := stmt.Close(); err != nil {
+		return nil, err
+	}
+
+	// COPY can't return generated keys, so read them back by username, which
+	// is unique.
+	var rows []struct {
+		ID       int64  `db:"id"`
+		Username string `db:"username"`
+	}
+	err = tx.SelectContext(ctx, &rows,
+		`SELECT id, username FROM users WHERE username = ANY($1)`,
+		pq.Array(usernames))
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		ids[row.Username] = row.ID
+	}
+
+	created := make([]model.User, 0, len(users))
+	for _, user := range users {
+		user.ID = ids[user.Username]
+		created = append(created, user)
+	}
+	return created, nil
+}
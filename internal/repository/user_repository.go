@@ -3,7 +3,10 @@ package repository
 import (
 	"context"
 	"fmt"
+	"petstore/internal/apperror"
 	"petstore/internal/model"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -11,28 +14,71 @@ import (
 type UserRepository interface {
 	Create(ctx context.Context, user model.User) (model.User, error)
 	CreateBatch(ctx context.Context, users []model.User) ([]model.User, error)
+	CreateEach(ctx context.Context, users []model.User) ([]model.User, []error, error)
+	ExistingUsernames(ctx context.Context, usernames []string) (map[string]bool, error)
+	ExistingEmails(ctx context.Context, emails []string) (map[string]bool, error)
 	FindByUsername(ctx context.Context, username string) (model.User, error)
+	FindByID(ctx context.Context, id int64) (model.User, error)
+	FindByEmail(ctx context.Context, email string) (model.User, error)
+	FindByExternalIdentity(ctx context.Context, provider, subject string) (model.User, error)
+	Search(ctx context.Context, search model.UserSearch) ([]model.User, int, error)
 	Update(ctx context.Context, username string, user model.User) (model.User, error)
+	UpdatePassword(ctx context.Context, id int64, passwordHash string) error
+	RevokeAccessTokens(ctx context.Context, id int64) error
+	UpdateStatus(ctx context.Context, id int64, status int) error
+	UpdateLastLogin(ctx context.Context, id int64) error
+	UpdateTOTP(ctx context.Context, id int64, secret string, enabled bool) error
+	UseTOTPStep(ctx context.Context, id int64, step int64) (bool, error)
+	UpdateExternalIdentity(ctx context.Context, id int64, identity model.ExternalIdentity) error
 	Delete(ctx context.Context, username string) error
 }
 
+const userColumns = `id, username, first_name, last_name, email, COALESCE(password, '') AS password,
+	phone, user_status, role, COALESCE(totp_secret, '') AS totp_secret, totp_enabled,
+	COALESCE(auth_provider, '') AS auth_provider, COALESCE(external_subject, '') AS external_subject,
+	last_login_at, token_version`
+
 type userRepo struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	timeout time.Duration
 }
 
-func NewUserRepository(db *sqlx.DB) UserRepository {
-	return &userRepo{db: db}
+func NewUserRepository(db *sqlx.DB, queryTimeout time.Duration) UserRepository {
+	return &userRepo{db: db, timeout: queryTimeout}
 }
 
 func (u *userRepo) Create(ctx context.Context, user model.User) (model.User, error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	return insertUser(ctx, conn(ctx, u.db), user)
+}
+
+func insertUser(ctx context.Context, q sqlx.QueryerContext, user model.User) (model.User, error) {
 	query := `
-		INSERT INTO users (username, first_name, last_name, email, password, phone, user_status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO users (username, first_name, last_name, email, password, phone, user_status,
+			role, auth_provider, external_subject)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE(NULLIF($8, ''), 'customer'), NULLIF($9, ''), NULLIF($10, ''))
 		RETURNING id;
 	`
 
 	var newID int
-	err := u.db.QueryRowContext(ctx, query,
+	err := q.QueryRowxContext(ctx, query, userInsertArgs(user)...).Scan(&newID)
+
+	if err != nil {
+		return user, fmt.Errorf("failed to insert user: %w", mapUniqueViolation(err, userValues(user)))
+	}
+
+	user.ID = int64(newID)
+	return user, nil
+}
+
+func userValues(user model.User) map[string]string {
+	return map[string]string{"username": user.Username, "email": user.Email}
+}
+
+func userInsertArgs(user model.User) []interface{} {
+	return []interface{}{
 		user.Username,
 		user.FirstName,
 		user.LastName,
@@ -40,54 +86,124 @@ func (u *userRepo) Create(ctx context.Context, user model.User) (model.User, err
 		user.Password,
 		user.Phone,
 		user.UserStatus,
-	).Scan(&newID)
+		user.Role,
+		user.AuthProvider,
+		user.ExternalSubject,
+	}
+}
+
+func (u *userRepo) FindByUsername(ctx context.Context, username string) (model.User, error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE LOWER(username) = LOWER($1)`
 
+	var user model.User
+
+	err := conn(ctx, u.db).GetContext(ctx, &user, query, username)
 	if err != nil {
-		return user, fmt.Errorf("failed to insert user: %w", err)
+		return user, findError(err, apperror.NotFound("user %s not found", username), "find user by username")
 	}
 
-	user.ID = int64(newID)
 	return user, nil
 }
 
-func (u *userRepo) CreateBatch(ctx context.Context, users []model.User) ([]model.User, error) {
-	createdUsers := make([]model.User, 0, len(users))
+func (u *userRepo) FindByID(ctx context.Context, id int64) (model.User, error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
 
-	for _, user := range users {
-		createdUser, err := u.Create(ctx, user)
-		if err != nil {
-			return nil, fmt.Errorf("failed creating users: %w", err)
-		}
-		createdUsers = append(createdUsers, createdUser)
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	var user model.User
+
+	err := conn(ctx, u.db).GetContext(ctx, &user, query, id)
+	if err != nil {
+		return user, findError(err, apperror.NotFound("user %d not found", id), "find user by id")
 	}
 
-	return createdUsers, nil
+	return user, nil
 }
 
-func (u *userRepo) FindByUsername(ctx context.Context, username string) (model.User, error) {
-	query := `
-		SELECT id, username, first_name, last_name, email, password, phone, user_status
-		FROM users WHERE username = $1
-	`
+func (u *userRepo) FindByEmail(ctx context.Context, email string) (model.User, error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE LOWER(email) = LOWER($1) AND email <> ''`
+
+	var user model.User
+
+	err := conn(ctx, u.db).GetContext(ctx, &user, query, email)
+	if err != nil {
+		return user, findError(err, apperror.NotFound("user not found"), "find user by email")
+	}
+
+	return user, nil
+}
+
+func (u *userRepo) FindByExternalIdentity(ctx context.Context, provider, subject string) (model.User, error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE auth_provider = $1 AND external_subject = $2`
 
 	var user model.User
 
-	err := u.db.GetContext(ctx, &user, query, username)
+	err := conn(ctx, u.db).GetContext(ctx, &user, query, provider, subject)
 	if err != nil {
-		return user, fmt.Errorf("failed to find user by username %s: %w", username, err)
+		return user, findError(err, apperror.NotFound("user not found"), "find user by external identity")
 	}
 
 	return user, nil
 }
 
+// Search returns one page of users matching the filter, ordered by username,
+// together with the total number of matches.
+func (u *userRepo) Search(ctx context.Context, search model.UserSearch) ([]model.User, int, error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	where := `
+		WHERE ($1 = '' OR username ILIKE $1 ESCAPE '\' OR email ILIKE $1 ESCAPE '\'
+			OR (COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) ILIKE $1 ESCAPE '\')
+		AND ($2::INT IS NULL OR user_status = $2)
+	`
+	pattern := ""
+	if search.Query != "" {
+		pattern = "%" + likeEscaper.Replace(search.Query) + "%"
+	}
+
+	var total int
+	err := conn(ctx, u.db).GetContext(ctx, &total, `SELECT COUNT(*) FROM users`+where, pattern, search.Status)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := `SELECT ` + userColumns + ` FROM users` + where + `
+		ORDER BY LOWER(username), id
+		LIMIT $3 OFFSET $4
+	`
+	users := []model.User{}
+	err = conn(ctx, u.db).SelectContext(ctx, &users, query, pattern, search.Status, search.Limit, search.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 func (u *userRepo) Update(ctx context.Context, username string, user model.User) (model.User, error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
 	query := `
 		UPDATE users
 		SET first_name = $1, last_name = $2, email = $3, password = $4, phone = $5, user_status = $6
-		WHERE username = $7
+		WHERE LOWER(username) = LOWER($7)
 	`
 
-	_, err := u.db.ExecContext(ctx, query,
+	_, err := conn(ctx, u.db).ExecContext(ctx, query,
 		user.FirstName,
 		user.LastName,
 		user.Email,
@@ -97,7 +213,7 @@ func (u *userRepo) Update(ctx context.Context, username string, user model.User)
 		username,
 	)
 	if err != nil {
-		return model.User{}, fmt.Errorf("failed to update user: %w", err)
+		return model.User{}, fmt.Errorf("failed to update user: %w", mapUniqueViolation(err, userValues(user)))
 	}
 
 	updatedUser, err := u.FindByUsername(ctx, username)
@@ -108,12 +224,136 @@ func (u *userRepo) Update(ctx context.Context, username string, user model.User)
 	return updatedUser, nil
 }
 
+func (u *userRepo) UpdatePassword(ctx context.Context, id int64, passwordHash string) error {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	query := `UPDATE users SET password = NULLIF($1, '') WHERE id = $2`
+
+	_, err := conn(ctx, u.db).ExecContext(ctx, query, passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return nil
+}
+
+// RevokeAccessTokens raises the token version of the user, so that access
+// tokens issued before are rejected.
+func (u *userRepo) RevokeAccessTokens(ctx context.Context, id int64) error {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	query := `UPDATE users SET token_version = token_version + 1 WHERE id = $1`
+
+	_, err := conn(ctx, u.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+	return nil
+}
+
+func (u *userRepo) UpdateStatus(ctx context.Context, id int64, status int) error {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	query := `UPDATE users SET user_status = $1 WHERE id = $2`
+
+	_, err := conn(ctx, u.db).ExecContext(ctx, query, status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+	return nil
+}
+
+func (u *userRepo) UpdateLastLogin(ctx context.Context, id int64) error {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	query := `UPDATE users SET last_login_at = NOW() WHERE id = $1`
+
+	_, err := conn(ctx, u.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to update last login: %w", err)
+	}
+	return nil
+}
+
+func (u *userRepo) UpdateTOTP(ctx context.Context, id int64, secret string, enabled bool) error {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	query := `UPDATE users SET totp_secret = NULLIF($1, ''), totp_enabled = $2 WHERE id = $3`
+
+	_, err := conn(ctx, u.db).ExecContext(ctx, query, secret, enabled, id)
+	if err != nil {
+		return fmt.Errorf("failed to update totp settings: %w", err)
+	}
+	return nil
+}
+
+// UseTOTPStep records step as the last accepted TOTP time step of the user.
+// It reports false if the same or a later step was accepted before, so that
+// each code works only once.
+func (u *userRepo) UseTOTPStep(ctx context.Context, id int64, step int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE users SET totp_last_step = $1
+		WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)
+	`
+
+	res, err := conn(ctx, u.db).ExecContext(ctx, query, step, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to record totp step: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record totp step: %w", err)
+	}
+	return n > 0, nil
+}
+
+// UpdateExternalIdentity links the user to the identity provider subject and
+// refreshes the fields the provider is authoritative for.
+func (u *userRepo) UpdateExternalIdentity(ctx context.Context, id int64, identity model.ExternalIdentity) error {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET auth_provider = $1, external_subject = $2, first_name = $3, last_name = $4, email = $5, role = $6
+		WHERE id = $7
+	`
+
+	_, err := conn(ctx, u.db).ExecContext(ctx, query,
+		identity.Provider,
+		identity.Subject,
+		identity.FirstName,
+		identity.LastName,
+		identity.Email,
+		identity.Role,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update external identity: %w",
+			mapUniqueViolation(err, map[string]string{"email": identity.Email}))
+	}
+	return nil
+}
+
 func (u *userRepo) Delete(ctx context.Context, username string) error {
-	query := `DELETE FROM users WHERE username = $1`
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
+
+	query := `DELETE FROM users WHERE LOWER(username) = LOWER($1)`
 
-	_, err := u.db.ExecContext(ctx, query, username)
+	res, err := conn(ctx, u.db).ExecContext(ctx, query, username)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return apperror.NotFound("user %s not found", username)
+	}
 	return nil
 }
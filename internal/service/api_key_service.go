@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"petstore/internal/apperror"
+	"petstore/internal/logging"
+	"petstore/internal/model"
+	"petstore/internal/repository"
+	"strings"
+)
+
+const apiKeyPrefix = "psk"
+
+var (
+	ErrInvalidAPIKey        = apperror.Unauthorized("invalid api key")
+	ErrAPIKeyNotFound       = apperror.NotFound("api key not found")
+	ErrInvalidAPIKeyRequest = apperror.Validation("invalid api key request")
+)
+
+type APIKeyService interface {
+	CreateKey(ctx context.Context, username string, req model.CreateAPIKeyRequest) (model.CreatedAPIKey, error)
+	ListKeys(ctx context.Context, username string) ([]model.APIKey, error)
+	RevokeKey(ctx context.Context, username string, keyID int64) error
+	Authenticate(ctx context.Context, rawKey string) (model.Principal, error)
+}
+
+type apiKeyService struct {
+	repo  repository.APIKeyRepository
+	users repository.UserRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, users repository.UserRepository) APIKeyService {
+	return &apiKeyService{repo: repo, users: users}
+}
+
+func (s *apiKeyService) CreateKey(ctx context.Context, username string, req model.CreateAPIKeyRequest) (model.CreatedAPIKey, error) {
+	if req.Name == "" {
+		return model.CreatedAPIKey{}, fmt.Errorf("%w: name cannot be empty", ErrInvalidAPIKeyRequest)
+	}
+	if len(req.Scopes) == 0 {
+		return model.CreatedAPIKey{}, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKeyRequest)
+	}
+	for _, scope := range req.Scopes {
+		if err := validateScope(scope); err != nil {
+			return model.CreatedAPIKey{}, err
+		}
+	}
+
+	user, err := s.users.FindByUsername(ctx, username)
+	if err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+
+	prefix, secret, err := generateAPIKey()
+	if err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+
+	key, err := s.repo.Create(ctx, model.APIKey{
+		UserID:     user.ID,
+		Name:       req.Name,
+		Prefix:     prefix,
+		SecretHash: hashToken(secret),
+		Scopes:     req.Scopes,
+	})
+	if err != nil {
+		return model.CreatedAPIKey{}, err
+	}
+	key.Username = user.Username
+	key.Role = user.Role
+
+	return model.CreatedAPIKey{
+		APIKey: key,
+		Key:    apiKeyPrefix + "_" + prefix + "_" + secret,
+	}, nil
+}
+
+func (s *apiKeyService) ListKeys(ctx context.Context, username string) ([]model.APIKey, error) {
+	user, err := s.users.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListByUser(ctx, user.ID)
+}
+
+func (s *apiKeyService) RevokeKey(ctx context.Context, username string, keyID int64) error {
+	user, err := s.users.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := s.repo.Revoke(ctx, user.ID, keyID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (model.Principal, error) {
+	parts := strings.Split(rawKey, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return model.Principal{}, ErrInvalidAPIKey
+	}
+	prefix, secret := parts[1], parts[2]
+
+	key, err := s.repo.FindByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Principal{}, ErrInvalidAPIKey
+		}
+		return model.Principal{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(hashToken(secret))) != 1 {
+		return model.Principal{}, ErrInvalidAPIKey
+	}
+	if key.RevokedAt != nil || key.UserStatus == model.UserStatusSuspended {
+		return model.Principal{}, ErrInvalidAPIKey
+	}
+
+	if err := s.repo.TouchLastUsed(ctx, key.ID); err != nil {
+		logging.FromContext(ctx).Warn("recording api key usage failed", "api_key_id", key.ID, "err", err)
+	}
+
+	scopes := []string(key.Scopes)
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return model.Principal{
+		Username: key.Username,
+		Role:     key.Role,
+		APIKeyID: key.ID,
+		Scopes:   scopes,
+	}, nil
+}
+
+func generateAPIKey() (string, string, error) {
+	prefix := make([]byte, 8)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	// Hex rather than base64url, which could contain the "_" separator.
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return hex.EncodeToString(prefix), hex.EncodeToString(secret), nil
+}
+
+func validateScope(scope string) error {
+	for _, s := range model.AllScopes {
+		if s == scope {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKeyRequest, scope)
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"fmt"
+	"petstore/internal/apperror"
+	"sync"
+	"time"
+)
+
+type ThrottlePolicy struct {
+	FreeAttempts     int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+type LoginThrottleConfig struct {
+	Username ThrottlePolicy
+	IP       ThrottlePolicy
+	// ResetAfter forgets a key once it has had no failures for this long.
+	ResetAfter time.Duration
+}
+
+var DefaultLoginThrottleConfig = LoginThrottleConfig{
+	Username: ThrottlePolicy{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+	},
+	IP: ThrottlePolicy{
+		FreeAttempts:     10,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute,
+		LockoutThreshold: 50,
+		LockoutDuration:  15 * time.Minute,
+	},
+	ResetAfter: time.Hour,
+}
+
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *TooManyAttemptsError) Kind() apperror.Kind {
+	return apperror.KindTooManyRequests
+}
+
+type attemptState struct {
+	failures     int
+	inFlight     int
+	blockedUntil time.Time
+	lastFailure  time.Time
+}
+
+// LoginThrottler counts failed logins per username and per client IP and
+// blocks further attempts with an exponentially growing delay.
+type LoginThrottler struct {
+	cfg       LoginThrottleConfig
+	mu        sync.Mutex
+	attempts  map[string]*attemptState
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+func NewLoginThrottler(cfg LoginThrottleConfig) *LoginThrottler {
+	return &LoginThrottler{
+		cfg:      cfg,
+		attempts: make(map[string]*attemptState),
+		now:      time.Now,
+	}
+}
+
+// LoginAttempt is a login attempt admitted by Check. It ends with Fail,
+// Succeed or Done.
+type LoginAttempt struct {
+	t        *LoginThrottler
+	username string
+	ip       string
+	ended    bool
+}
+
+// Check returns an error if either the username or the IP is currently
+// blocked, counting the attempts still in progress as failed, so that
+// parallel guesses can't all get in before the first failure is recorded.
+// Otherwise it reserves the attempt.
+func (t *LoginThrottler) Check(username, ip string) (*LoginAttempt, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.prune(now)
+
+	var wait time.Duration
+	for key, policy := range t.keys(username, ip) {
+		if st, ok := t.attempts[key]; ok {
+			wait = max(wait, st.wait(policy, now))
+		}
+	}
+	if wait > 0 {
+		return nil, &TooManyAttemptsError{RetryAfter: wait}
+	}
+
+	for key := range t.keys(username, ip) {
+		t.state(key).inFlight++
+	}
+	return &LoginAttempt{t: t, username: username, ip: ip}, nil
+}
+
+// Fail ends the attempt as a failed login.
+func (a *LoginAttempt) Fail() {
+	a.end(func(key string, policy ThrottlePolicy, st *attemptState, now time.Time) {
+		st.failures++
+		st.lastFailure = now
+		if d := policy.delay(st.failures); d > 0 {
+			st.blockedUntil = now.Add(d)
+		}
+	})
+}
+
+// Succeed ends the attempt and clears the username counter. The IP counter is
+// left alone so that logging into an own account does not reset guesses
+// against other accounts.
+func (a *LoginAttempt) Succeed() {
+	a.end(func(key string, _ ThrottlePolicy, st *attemptState, _ time.Time) {
+		if key == "user:"+a.username {
+			*st = attemptState{inFlight: st.inFlight}
+		}
+	})
+}
+
+// Done ends an attempt that neither failed nor succeeded, for example one
+// that stopped at a database error. It does nothing after Fail or Succeed,
+// so it can be deferred.
+func (a *LoginAttempt) Done() {
+	a.end(func(string, ThrottlePolicy, *attemptState, time.Time) {})
+}
+
+func (a *LoginAttempt) end(update func(key string, policy ThrottlePolicy, st *attemptState, now time.Time)) {
+	t := a.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if a.ended {
+		return
+	}
+	a.ended = true
+
+	now := t.now()
+	for key, policy := range t.keys(a.username, a.ip) {
+		st := t.state(key)
+		st.inFlight--
+		update(key, policy, st, now)
+	}
+}
+
+// wait is how long the key is blocked if the attempts in flight fail.
+func (st *attemptState) wait(policy ThrottlePolicy, now time.Time) time.Duration {
+	until := st.blockedUntil
+	if st.inFlight > 0 {
+		if d := policy.delay(st.failures + st.inFlight); d > 0 && now.Add(d).After(until) {
+			until = now.Add(d)
+		}
+	}
+	return max(until.Sub(now), 0)
+}
+
+// delay is how long to block a key after the given number of failures.
+func (p ThrottlePolicy) delay(failures int) time.Duration {
+	switch {
+	case p.LockoutThreshold > 0 && failures >= p.LockoutThreshold:
+		return p.LockoutDuration
+	case failures > p.FreeAttempts:
+		delay := p.BaseDelay << (failures - p.FreeAttempts - 1)
+		if delay <= 0 || delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+		return delay
+	}
+	return 0
+}
+
+func (t *LoginThrottler) state(key string) *attemptState {
+	st, ok := t.attempts[key]
+	if !ok {
+		st = &attemptState{}
+		t.attempts[key] = st
+	}
+	return st
+}
+
+func (t *LoginThrottler) prune(now time.Time) {
+	if now.Sub(t.lastPrune) < time.Minute {
+		return
+	}
+	t.lastPrune = now
+
+	for key, st := range t.attempts {
+		if st.inFlight == 0 && st.blockedUntil.Before(now) && now.Sub(st.lastFailure) > t.cfg.ResetAfter {
+			delete(t.attempts, key)
+		}
+	}
+}
+
+func (t *LoginThrottler) keys(username, ip string) map[string]ThrottlePolicy {
+	keys := map[string]ThrottlePolicy{"user:" + username: t.cfg.Username}
+	if ip != "" {
+		keys["ip:"+ip] = t.cfg.IP
+	}
+	return keys
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testThrottlePolicy = ThrottlePolicy{
+	FreeAttempts:     2,
+	BaseDelay:        time.Second,
+	MaxDelay:         time.Minute,
+	LockoutThreshold: 5,
+	LockoutDuration:  15 * time.Minute,
+}
+
+// newTestThrottler returns a throttler with a clock that only moves when the
+// returned function is called.
+func newTestThrottler() (*LoginThrottler, func(time.Duration)) {
+	t := NewLoginThrottler(LoginThrottleConfig{
+		Username:   testThrottlePolicy,
+		IP:         ThrottlePolicy{FreeAttempts: 100},
+		ResetAfter: time.Hour,
+	})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	t.now = func() time.Time { return now }
+	return t, func(d time.Duration) { now = now.Add(d) }
+}
+
+// retryAfter returns how long Check says to wait, or 0 if it admits the
+// attempt, which it then ends with end.
+func retryAfter(t *testing.T, th *LoginThrottler, end func(*LoginAttempt)) time.Duration {
+	t.Helper()
+	attempt, err := th.Check("jane", "192.0.2.1")
+	var tooMany *TooManyAttemptsError
+	if errors.As(err, &tooMany) {
+		return tooMany.RetryAfter
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	end(attempt)
+	return 0
+}
+
+func TestLoginThrottleDelaysAndLocksOut(t *testing.T) {
+	th, advance := newTestThrottler()
+	fail := (*LoginAttempt).Fail
+
+	for i := range testThrottlePolicy.FreeAttempts {
+		if wait := retryAfter(t, th, fail); wait != 0 {
+			t.Fatalf("free attempt %d blocked for %s, want it admitted", i+1, wait)
+		}
+	}
+
+	// Every further failure doubles the delay until the lockout threshold
+	// is reached.
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, testThrottlePolicy.LockoutDuration} {
+		if wait := retryAfter(t, th, fail); wait != 0 {
+			t.Fatalf("attempt blocked for %s after waiting, want it admitted", wait)
+		}
+		if wait := retryAfter(t, th, fail); wait != want {
+			t.Fatalf("got retry after %s, want %s", wait, want)
+		}
+		advance(want)
+	}
+}
+
+func TestLoginThrottleSucceedResetsUsername(t *testing.T) {
+	th, advance := newTestThrottler()
+	fail := (*LoginAttempt).Fail
+
+	for range testThrottlePolicy.FreeAttempts + 1 {
+		retryAfter(t, th, fail)
+	}
+	wait := retryAfter(t, th, fail)
+	if wait == 0 {
+		t.Fatal("attempt admitted, want it delayed after repeated failures")
+	}
+	advance(wait)
+	if wait := retryAfter(t, th, (*LoginAttempt).Succeed); wait != 0 {
+		t.Fatalf("attempt blocked for %s after waiting, want it admitted", wait)
+	}
+
+	// After a success the free attempts start over.
+	for i := range testThrottlePolicy.FreeAttempts + 1 {
+		if wait := retryAfter(t, th, fail); wait != 0 {
+			t.Fatalf("attempt %d after success blocked for %s, want it admitted", i+1, wait)
+		}
+	}
+}
+
+func TestLoginThrottleCountsAttemptsInFlight(t *testing.T) {
+	th, _ := newTestThrottler()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		admitted []*LoginAttempt
+	)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempt, err := th.Check("jane", "192.0.2.1")
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			admitted = append(admitted, attempt)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// As many guesses get in at once as one after the other.
+	if want := testThrottlePolicy.FreeAttempts + 1; len(admitted) != want {
+		t.Errorf("admitted %d parallel attempts, want %d", len(admitted), want)
+	}
+	for _, attempt := range admitted {
+		attempt.Fail()
+	}
+	if _, err := th.Check("jane", "192.0.2.1"); err == nil {
+		t.Error("attempt admitted after the parallel guesses failed, want it delayed")
+	}
+}
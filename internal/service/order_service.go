@@ -2,27 +2,76 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"petstore/internal/apperror"
+	"petstore/internal/metrics"
 	"petstore/internal/model"
 	"petstore/internal/repository"
 )
 
 type OrderService interface {
-	CreateOrder(ctx context.Context, order model.Order) (model.Order, error)
+	CreateOrder(ctx context.Context, order model.Order, customer string) (model.Order, error)
 	FindOrderByID(ctx context.Context, orderID int) (model.Order, error)
 	DeleteOrder(ctx context.Context, orderID int) error
 	GetInventory(ctx context.Context) (map[string]int, error)
 }
 
 type orderService struct {
-	repo repository.OrderRepository
+	repo    repository.OrderRepository
+	pets    repository.PetRepository
+	users   repository.UserRepository
+	tx      repository.TxManager
+	metrics *metrics.Metrics
 }
 
-func NewOrderService(repo repository.OrderRepository) OrderService {
-	return &orderService{repo: repo}
+func NewOrderService(
+	repo repository.OrderRepository,
+	pets repository.PetRepository,
+	users repository.UserRepository,
+	tx repository.TxManager,
+	m *metrics.Metrics,
+) OrderService {
+	return &orderService{repo: repo, pets: pets, users: users, tx: tx, metrics: m}
 }
 
-func (o *orderService) CreateOrder(ctx context.Context, order model.Order) (model.Order, error) {
-	return o.repo.Create(ctx, order)
+// CreateOrder stores the order, linked to the customer's account when the
+// order was placed by a logged in user. customer is empty for guest orders.
+// Orders without a status start as placed. The pet is checked in the same
+// transaction as the insert, so a missing pet is reported as an invalid
+// petId rather than as a foreign key violation.
+func (o *orderService) CreateOrder(ctx context.Context, order model.Order, customer string) (model.Order, error) {
+	if order.Status == "" {
+		order.Status = "placed"
+	}
+	order.UserID = nil
+	if customer != "" {
+		user, err := o.users.FindByUsername(ctx, customer)
+		if err != nil {
+			return model.Order{}, fmt.Errorf("failed to find customer: %w", err)
+		}
+		order.UserID = &user.ID
+	}
+
+	var created model.Order
+	err := o.tx.WithinTx(ctx, func(ctx context.Context) error {
+		exists, err := o.pets.ExistsByID(ctx, order.PetID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			var errs apperror.FieldErrors
+			errs.Add("petId", apperror.CodeInvalid, "pet with ID %d does not exist", order.PetID)
+			return errs.Err()
+		}
+
+		created, err = o.repo.Create(ctx, order)
+		return err
+	})
+	if err != nil {
+		return model.Order{}, err
+	}
+	o.metrics.OrderPlaced()
+	return created, nil
 }
 
 func (o *orderService) FindOrderByID(ctx context.Context, orderID int) (model.Order, error) {
@@ -1,14 +1,259 @@
 package service
 
 import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"petstore/internal/apperror"
+	"petstore/internal/config"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func hashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+const (
+	argon2Prefix  = "$argon2id$"
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+	// bcrypt ignores everything past 72 bytes.
+	bcryptMaxLength = 72
+)
+
+var errPasswordMismatch = errors.New("password does not match")
+
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// PasswordManager enforces the password policy and hashes passwords with the
+// configured algorithm. Stored hashes are verified with whatever algorithm
+// produced them, detected from their prefix.
+type PasswordManager struct {
+	cfg      config.PasswordConfig
+	breached map[string]struct{}
+
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+func NewPasswordManager(cfg config.PasswordConfig) (*PasswordManager, error) {
+	switch cfg.Algorithm {
+	case config.PasswordAlgorithmBcrypt:
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		if cfg.MaxLength <= 0 || cfg.MaxLength > bcryptMaxLength {
+			cfg.MaxLength = bcryptMaxLength
+		}
+	case config.PasswordAlgorithmArgon2id:
+		if cfg.Argon2Time == 0 || cfg.Argon2Memory == 0 || cfg.Argon2Threads == 0 {
+			return nil, fmt.Errorf("argon2 time, memory and threads must be positive")
+		}
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm %q", cfg.Algorithm)
+	}
+
+	m := &PasswordManager{cfg: cfg, breached: make(map[string]struct{})}
+	if !cfg.CheckBreached {
+		return m, nil
+	}
+
+	if err := m.addBreached(strings.NewReader(commonPasswords)); err != nil {
+		return nil, err
+	}
+	if cfg.BreachedListFile != "" {
+		f, err := os.Open(cfg.BreachedListFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open breached password list: %w", err)
+		}
+		defer f.Close()
+		if err := m.addBreached(f); err != nil {
+			return nil, fmt.Errorf("failed to read breached password list: %w", err)
+		}
+	}
+	return m, nil
+}
+
+func (m *PasswordManager) addBreached(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m.breached[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Validate checks password against the policy and reports every rule it
+// breaks as a field error on "password". username may be empty when it is not
+// known yet.
+func (m *PasswordManager) Validate(password, username string) error {
+	var errs apperror.FieldErrors
+	if password == "" {
+		errs.Add("password", apperror.CodeRequired, "password is required")
+		return errs.Err()
+	}
+
+	if n := utf8.RuneCountInString(password); n < m.cfg.MinLength {
+		errs.Add("password", apperror.CodeTooShort, "password must be at least %d characters", m.cfg.MinLength)
+	}
+	if m.cfg.MaxLength > 0 && len(password) > m.cfg.MaxLength {
+		errs.Add("password", apperror.CodeTooLong, "password must be at most %d bytes", m.cfg.MaxLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if m.cfg.RequireUpper && !upper {
+		errs.Add("password", "missing_upper", "password must contain an upper-case letter")
+	}
+	if m.cfg.RequireLower && !lower {
+		errs.Add("password", "missing_lower", "password must contain a lower-case letter")
+	}
+	if m.cfg.RequireDigit && !digit {
+		errs.Add("password", "missing_digit", "password must contain a digit")
+	}
+	if m.cfg.RequireSymbol && !symbol {
+		errs.Add("password", "missing_symbol", "password must contain a symbol")
+	}
+
+	if username != "" && strings.EqualFold(password, username) {
+		errs.Add("password", "same_as_username", "password must not be the same as the username")
+	}
+	if _, ok := m.breached[strings.ToLower(password)]; ok {
+		errs.Add("password", "breached", "password is too common or has appeared in a data breach")
+	}
+
+	return errs.Err()
+}
+
+func (m *PasswordManager) Hash(password string) (string, error) {
+	if m.cfg.Algorithm == config.PasswordAlgorithmArgon2id {
+		return m.hashArgon2(password)
+	}
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), m.cfg.BcryptCost)
 	return string(bytes), err
 }
 
-func checkPasswordHash(hash, password string) error {
+// Verify returns nil if password matches hash.
+func (m *PasswordManager) Verify(hash, password string) error {
+	if strings.HasPrefix(hash, argon2Prefix) {
+		return verifyArgon2(hash, password)
+	}
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
+
+// NeedsRehash reports whether hash was made with a different algorithm or
+// different parameters than the current configuration.
+func (m *PasswordManager) NeedsRehash(hash string) bool {
+	if strings.HasPrefix(hash, argon2Prefix) {
+		if m.cfg.Algorithm != config.PasswordAlgorithmArgon2id {
+			return true
+		}
+		p, _, _, err := decodeArgon2(hash)
+		return err != nil || p != m.argon2Params()
+	}
+
+	if m.cfg.Algorithm != config.PasswordAlgorithmBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != m.cfg.BcryptCost
+}
+
+// DummyHash returns a valid hash that matches no real password, so that
+// unknown usernames cost the same time to reject as wrong passwords.
+func (m *PasswordManager) DummyHash() string {
+	m.dummyOnce.Do(func() {
+		m.dummyHash, _ = m.Hash("petstore-dummy-password")
+	})
+	return m.dummyHash
+}
+
+type argon2Params struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+func (m *PasswordManager) argon2Params() argon2Params {
+	return argon2Params{time: m.cfg.Argon2Time, memory: m.cfg.Argon2Memory, threads: m.cfg.Argon2Threads}
+}
+
+func (m *PasswordManager) hashArgon2(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	p := m.argon2Params()
+	key := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, argon2KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2Prefix, argon2.Version, p.memory, p.time, p.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func verifyArgon2(hash, password string) error {
+	p, salt, key, err := decodeArgon2(hash)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return errPasswordMismatch
+	}
+	return nil
+}
+
+// decodeArgon2 parses the PHC string format
+// $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>.
+func decodeArgon2(hash string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, errors.New("malformed argon2 hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return p, nil, nil, fmt.Errorf("malformed argon2 parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("malformed argon2 salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("malformed argon2 key: %w", err)
+	}
+	return p, salt, key, nil
+}
@@ -2,10 +2,9 @@ package service
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
-	"log"
+	"petstore/internal/apperror"
+	"petstore/internal/metrics"
 	"petstore/internal/model"
 	"petstore/internal/repository"
 )
@@ -21,11 +20,13 @@ type PetService interface {
 }
 
 type petService struct {
-	repo repository.PetRepository
+	repo    repository.PetRepository
+	tx      repository.TxManager
+	metrics *metrics.Metrics
 }
 
-func NewPetService(repo repository.PetRepository) PetService {
-	return &petService{repo: repo}
+func NewPetService(repo repository.PetRepository, tx repository.TxManager, m *metrics.Metrics) PetService {
+	return &petService{repo: repo, tx: tx, metrics: m}
 }
 
 func (s *petService) CreatePet(ctx context.Context, pet model.Pet) (model.Pet, error) {
@@ -33,36 +34,46 @@ func (s *petService) CreatePet(ctx context.Context, pet model.Pet) (model.Pet, e
 }
 
 func (s *petService) UpdatePet(ctx context.Context, pet model.Pet) (model.Pet, error) {
-	err := ValidatePet(pet)
-	if err != nil {
-		return model.Pet{}, fmt.Errorf("incorrect data :%w", err)
-	}
-	exists, err := s.repo.ExistsByID(ctx, pet.ID)
-	if err != nil {
-		return model.Pet{}, fmt.Errorf("error checking pet existence: %w", err)
-	}
-	if !exists {
-		log.Printf("Pet with ID %d not found", pet.ID)
-		return model.Pet{}, fmt.Errorf("pet with ID %d not found", pet.ID)
+	if pet.ID <= 0 {
+		var errs apperror.FieldErrors
+		errs.Add("id", apperror.CodeRequired, "id is required")
+		return model.Pet{}, errs.Err()
 	}
-	return s.repo.Update(ctx, pet)
+	return s.update(ctx, pet.ID, func(ctx context.Context) (model.Pet, error) {
+		return s.repo.Update(ctx, pet)
+	})
 }
 
 func (s *petService) UpdatePetFormData(ctx context.Context, petID int, name, status string) (model.Pet, error) {
-	err := ValidatePetFormData(name, status)
-	if err != nil {
-		return model.Pet{}, fmt.Errorf("incorrect data :%w", err)
-	}
-	exists, err := s.repo.ExistsByID(ctx, petID)
+	return s.update(ctx, petID, func(ctx context.Context) (model.Pet, error) {
+		return s.repo.UpdateFormData(ctx, petID, name, status)
+	})
+}
+
+// update runs write in a transaction with the read of the current status,
+// so that concurrent updates can't count the same sale twice.
+func (s *petService) update(ctx context.Context, petID int, write func(ctx context.Context) (model.Pet, error)) (model.Pet, error) {
+	var before, updated model.Pet
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if before, err = s.repo.FindByID(ctx, petID); err != nil {
+			return err
+		}
+		updated, err = write(ctx)
+		return err
+	})
 	if err != nil {
-		return model.Pet{}, fmt.Errorf("error checking pet existence: %w", err)
+		return model.Pet{}, err
 	}
-	if !exists {
-		return model.Pet{}, fmt.Errorf("pet with ID %d not found", petID)
-	}
-
-	return s.repo.UpdateFormData(ctx, petID, name, status)
+	s.countSale(before.Status, updated.Status)
+	return updated, nil
+}
 
+// countSale counts a pet as sold when its status changes to sold.
+func (s *petService) countSale(before, after string) {
+	if after == "sold" && before != "sold" {
+		s.metrics.PetSold()
+	}
 }
 
 func (s *petService) FindPetByID(ctx context.Context, petID int) (model.Pet, error) {
@@ -72,15 +83,11 @@ func (s *petService) FindPetByID(ctx context.Context, petID int) (model.Pet, err
 func (s *petService) FindPetByStatus(ctx context.Context, statuses []string) ([]model.Pet, error) {
 	for _, status := range statuses {
 		if err := validatePetStatus(status); err != nil {
-			return nil, fmt.Errorf("invalid pet status: %w", err)
+			return nil, err
 		}
 	}
 	pets, err := s.repo.FindByStatus(ctx, statuses)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			log.Println("No pets found for given statuses")
-			return nil, fmt.Errorf("no pets found for given statuses")
-		}
 		return nil, fmt.Errorf("error finding pets: %w", err)
 	}
 	return pets, nil
@@ -88,7 +95,7 @@ func (s *petService) FindPetByStatus(ctx context.Context, statuses []string) ([]
 
 func (s *petService) FindPetByTags(ctx context.Context, tags []string) ([]model.Pet, error) {
 	if len(tags) == 0 {
-		return nil, fmt.Errorf("tag list cannot be empty")
+		return nil, apperror.Validation("tag list cannot be empty")
 	}
 	err := validateTags(tags)
 	if err != nil {
@@ -97,10 +104,6 @@ func (s *petService) FindPetByTags(ctx context.Context, tags []string) ([]model.
 
 	pets, err := s.repo.FindByTags(ctx, tags)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			log.Println("No pets found for given tags")
-			return nil, fmt.Errorf("no pets found for given tags")
-		}
 		return nil, fmt.Errorf("error finding pets: %w", err)
 	}
 	return pets, nil
@@ -110,43 +113,18 @@ func (s *petService) DeletePet(ctx context.Context, petID int) error {
 	return s.repo.Delete(ctx, petID)
 }
 
-func ValidatePet(pet model.Pet) error {
-
-	if pet.Name == "" {
-		return fmt.Errorf("name cannot be empty")
-	}
-	if pet.ID <= 0 {
-		return fmt.Errorf("invalid pet id")
-	}
-	if validatePetStatus(pet.Status) != nil {
-		return fmt.Errorf("invalid pet status")
-	}
-	return nil
-}
-
-func ValidatePetFormData(name, status string) error {
-	if name == "" {
-		return fmt.Errorf("name cannot be empty")
-	}
-	return validatePetStatus(status)
-}
-
 func validatePetStatus(status string) error {
-	var validStatuses = map[string]struct{}{
-		"available": {},
-		"pending":   {},
-		"sold":      {},
-	}
-	if _, ok := validStatuses[status]; !ok {
-		return fmt.Errorf("invalid pet status")
+	switch status {
+	case "available", "pending", "sold":
+		return nil
 	}
-	return nil
+	return apperror.Validation("invalid pet status")
 }
 
 func validateTags(tags []string) error {
 	for _, tag := range tags {
 		if tag == "" {
-			return fmt.Errorf("tag cannot be empty")
+			return apperror.Validation("tag cannot be empty")
 		}
 	}
 	return nil
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"petstore/internal/apperror"
+	"petstore/internal/model"
+	"petstore/internal/repository"
+	"time"
+)
+
+var (
+	ErrErasureRequestNotFound = apperror.NotFound("erasure request not found or already decided")
+	ErrErasureAlreadyPending  = apperror.Conflict("an erasure request is already pending")
+	ErrUserErased             = apperror.Conflict("user data has been erased")
+	ErrInvalidErasureStatus   = apperror.Validation("invalid erasure request status")
+)
+
+// PrivacyService handles data subject requests: exporting a user's data and
+// erasing it after an admin has approved the request.
+type PrivacyService interface {
+	ExportUserData(ctx context.Context, username string) (model.UserDataExport, error)
+	RequestErasure(ctx context.Context, username string) (model.ErasureRequest, error)
+	ListErasureRequests(ctx context.Context, status string) ([]model.ErasureRequest, error)
+	ApproveErasure(ctx context.Context, id int64, admin string) (model.ErasureRequest, error)
+	RejectErasure(ctx context.Context, id int64, admin string) (model.ErasureRequest, error)
+}
+
+type privacyService struct {
+	users    repository.UserRepository
+	orders   repository.OrderRepository
+	apiKeys  repository.APIKeyRepository
+	erasures repository.ErasureRepository
+}
+
+func NewPrivacyService(
+	users repository.UserRepository,
+	orders repository.OrderRepository,
+	apiKeys repository.APIKeyRepository,
+	erasures repository.ErasureRepository,
+) PrivacyService {
+	return &privacyService{users: users, orders: orders, apiKeys: apiKeys, erasures: erasures}
+}
+
+func (s *privacyService) ExportUserData(ctx context.Context, username string) (model.UserDataExport, error) {
+	user, err := s.users.FindByUsername(ctx, username)
+	if err != nil {
+		return model.UserDataExport{}, err
+	}
+
+	orders, err := s.orders.ListByUser(ctx, user.ID)
+	if err != nil {
+		return model.UserDataExport{}, err
+	}
+	keys, err := s.apiKeys.ListByUser(ctx, user.ID)
+	if err != nil {
+		return model.UserDataExport{}, err
+	}
+	erasures, err := s.erasures.ListByUser(ctx, user.ID)
+	if err != nil {
+		return model.UserDataExport{}, err
+	}
+
+	return model.UserDataExport{
+		ExportedAt:      time.Now().UTC(),
+		User:            user,
+		Orders:          orders,
+		APIKeys:         keys,
+		ErasureRequests: erasures,
+	}, nil
+}
+
+func (s *privacyService) RequestErasure(ctx context.Context, username string) (model.ErasureRequest, error) {
+	user, err := s.users.FindByUsername(ctx, username)
+	if err != nil {
+		return model.ErasureRequest{}, err
+	}
+	if user.UserStatus == model.UserStatusErased {
+		return model.ErasureRequest{}, ErrUserErased
+	}
+
+	req, err := s.erasures.Create(ctx, user.ID)
+	if err != nil {
+		var conflict *repository.ConflictError
+		if errors.As(err, &conflict) {
+			return model.ErasureRequest{}, ErrErasureAlreadyPending
+		}
+		return model.ErasureRequest{}, err
+	}
+	return req, nil
+}
+
+func (s *privacyService) ListErasureRequests(ctx context.Context, status string) ([]model.ErasureRequest, error) {
+	switch status {
+	case "", model.ErasureStatusPending, model.ErasureStatusApproved, model.ErasureStatusRejected:
+	default:
+		return nil, fmt.Errorf("%w %q", ErrInvalidErasureStatus, status)
+	}
+	return s.erasures.List(ctx, status)
+}
+
+func (s *privacyService) ApproveErasure(ctx context.Context, id int64, admin string) (model.ErasureRequest, error) {
+	req, err := s.erasures.Approve(ctx, id, admin)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErasureRequest{}, ErrErasureRequestNotFound
+	}
+	return req, err
+}
+
+func (s *privacyService) RejectErasure(ctx context.Context, id int64, admin string) (model.ErasureRequest, error) {
+	req, err := s.erasures.Reject(ctx, id, admin)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErasureRequest{}, ErrErasureRequestNotFound
+	}
+	return req, err
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Key is the SHA-1 seed of the test vectors in RFC 6238 Appendix B.
+var rfc6238Key = []byte("12345678901234567890")
+
+func TestHOTPMatchesRFC6238Vectors(t *testing.T) {
+	// The RFC lists eight digit codes; a six digit code is their last six
+	// digits.
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, v := range vectors {
+		want := v.code[len(v.code)-totpDigits:]
+		if got := hotp(rfc6238Key, uint64(v.unix/totpPeriod)); got != want {
+			t.Errorf("code at %d = %s, want %s", v.unix, got, want)
+		}
+	}
+}
+
+func TestMatchTOTPAcceptsOneStepOfDrift(t *testing.T) {
+	secret := totpEncoding.EncodeToString(rfc6238Key)
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+
+	for offset := int64(-3); offset <= 3; offset++ {
+		code := hotp(rfc6238Key, uint64(step+offset))
+		got, ok := matchTOTP(secret, code, now)
+
+		inWindow := offset >= -totpSkew && offset <= totpSkew
+		if ok != inWindow {
+			t.Errorf("code %d steps away: accepted = %v, want %v", offset, ok, inWindow)
+		}
+		if ok && got != step+offset {
+			t.Errorf("code %d steps away matched step %d, want %d", offset, got, step+offset)
+		}
+	}
+
+	if _, ok := matchTOTP(secret, "12345", now); ok {
+		t.Error("accepted a code with too few digits")
+	}
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"petstore/internal/model"
+	"petstore/internal/tracing"
+)
+
+// startSpan starts the span of a service call. The returned function ends it
+// with the call's error.
+func startSpan(ctx context.Context, name string) (context.Context, func(error)) {
+	ctx, span := tracing.Start(ctx, name)
+	return ctx, func(err error) { tracing.End(span, err) }
+}
+
+type tracedPetService struct {
+	next PetService
+}
+
+// TracePetService wraps next so that every call gets its own span.
+func TracePetService(next PetService) PetService {
+	return &tracedPetService{next: next}
+}
+
+func (s *tracedPetService) CreatePet(ctx context.Context, pet model.Pet) (model.Pet, error) {
+	ctx, done := startSpan(ctx, "PetService.CreatePet")
+	res, err := s.next.CreatePet(ctx, pet)
+	done(err)
+	return res, err
+}
+
+func (s *tracedPetService) UpdatePet(ctx context.Context, pet model.Pet) (model.Pet, error) {
+	ctx, done := startSpan(ctx, "PetService.UpdatePet")
+	res, err := s.next.UpdatePet(ctx, pet)
+	done(err)
+	return res, err
+}
+
+func (s *tracedPetService) UpdatePetFormData(ctx context.Context, petID int, name, status string) (model.Pet, error) {
+	ctx, done := startSpan(ctx, "PetService.UpdatePetFormData")
+	res, err := s.next.UpdatePetFormData(ctx, petID, name, status)
+	done(err)
+	return res, err
+}
+
+func (s *tracedPetService) FindPetByID(ctx context.Context, petID int) (model.Pet, error) {
+	ctx, done := startSpan(ctx, "PetService.FindPetByID")
+	res, err := s.next.FindPetByID(ctx, petID)
+	done(err)
+	return res, err
+}
+
+func (s *tracedPetService) FindPetByStatus(ctx context.Context, statuses []string) ([]model.Pet, error) {
+	ctx, done := startSpan(ctx, "PetService.FindPetByStatus")
+	res, err := s.next.FindPetByStatus(ctx, statuses)
+	done(err)
+	return res, err
+}
+
+func (s *tracedPetService) FindPetByTags(ctx context.Context, tags []string) ([]model.Pet, error) {
+	ctx, done := startSpan(ctx, "PetService.FindPetByTags")
+	res, err := s.next.FindPetByTags(ctx, tags)
+	done(err)
+	return res, err
+}
+
+func (s *tracedPetService) DeletePet(ctx context.Context, petID int) error {
+	ctx, done := startSpan(ctx, "PetService.DeletePet")
+	err := s.next.DeletePet(ctx, petID)
+	done(err)
+	return err
+}
+
+type tracedOrderService struct {
+	next OrderService
+}
+
+// TraceOrderService wraps next so that every call gets its own span.
+func TraceOrderService(next OrderService) OrderService {
+	return &tracedOrderService{next: next}
+}
+
+func (s *tracedOrderService) CreateOrder(ctx context.Context, order model.Order, customer string) (model.Order, error) {
+	ctx, done := startSpan(ctx, "OrderService.CreateOrder")
+	res, err := s.next.CreateOrder(ctx, order, customer)
+	done(err)
+	return res, err
+}
+
+func (s *tracedOrderService) FindOrderByID(ctx context.Context, orderID int) (model.Order, error) {
+	ctx, done := startSpan(ctx, "OrderService.FindOrderByID")
+	res, err := s.next.FindOrderByID(ctx, orderID)
+	done(err)
+	return res, err
+}
+
+func (s *tracedOrderService) DeleteOrder(ctx context.Context, orderID int) error {
+	ctx, done := startSpan(ctx, "OrderService.DeleteOrder")
+	err := s.next.DeleteOrder(ctx, orderID)
+	done(err)
+	return err
+}
+
+func (s *tracedOrderService) GetInventory(ctx context.Context) (map[string]int, error) {
+	ctx, done := startSpan(ctx, "OrderService.GetInventory")
+	res, err := s.next.GetInventory(ctx)
+	done(err)
+	return res, err
+}
+
+type tracedUserService struct {
+	next UserService
+}
+
+// TraceUserService wraps next so that every call gets its own span.
+func TraceUserService(next UserService) UserService {
+	return &tracedUserService{next: next}
+}
+
+func (s *tracedUserService) CreateUser(ctx context.Context, user model.User) (model.User, error) {
+	ctx, done := startSpan(ctx, "UserService.CreateUser")
+	res, err := s.next.CreateUser(ctx, user)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) CreateUserBatch(ctx context.Context, items []UserBatchItem, mode string) ([]UserBatchItem, error) {
+	ctx, done := startSpan(ctx, "UserService.CreateUserBatch")
+	res, err := s.next.CreateUserBatch(ctx, items, mode)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) FindUserByUsername(ctx context.Context, username string) (model.User, error) {
+	ctx, done := startSpan(ctx, "UserService.FindUserByUsername")
+	res, err := s.next.FindUserByUsername(ctx, username)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) UpdateUser(ctx context.Context, username string, user model.User) (model.User, error) {
+	ctx, done := startSpan(ctx, "UserService.UpdateUser")
+	res, err := s.next.UpdateUser(ctx, username, user)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) DeleteUser(ctx context.Context, username string) error {
+	ctx, done := startSpan(ctx, "UserService.DeleteUser")
+	err := s.next.DeleteUser(ctx, username)
+	done(err)
+	return err
+}
+
+func (s *tracedUserService) Login(ctx context.Context, username, password, clientIP string) (model.LoginResult, error) {
+	ctx, done := startSpan(ctx, "UserService.Login")
+	res, err := s.next.Login(ctx, username, password, clientIP)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) CompleteTwoFactorLogin(ctx context.Context, challengeToken, code, clientIP string) (string, error) {
+	ctx, done := startSpan(ctx, "UserService.CompleteTwoFactorLogin")
+	res, err := s.next.CompleteTwoFactorLogin(ctx, challengeToken, code, clientIP)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) EnrollTOTP(ctx context.Context, username string) (model.TOTPEnrollment, error) {
+	ctx, done := startSpan(ctx, "UserService.EnrollTOTP")
+	res, err := s.next.EnrollTOTP(ctx, username)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) ConfirmTOTP(ctx context.Context, username, code string) ([]string, error) {
+	ctx, done := startSpan(ctx, "UserService.ConfirmTOTP")
+	res, err := s.next.ConfirmTOTP(ctx, username, code)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) LoginExternal(ctx context.Context, identity model.ExternalIdentity) (string, error) {
+	ctx, done := startSpan(ctx, "UserService.LoginExternal")
+	res, err := s.next.LoginExternal(ctx, identity)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) SearchUsers(ctx context.Context, search model.UserSearch) ([]model.User, int, error) {
+	ctx, done := startSpan(ctx, "UserService.SearchUsers")
+	users, total, err := s.next.SearchUsers(ctx, search)
+	done(err)
+	return users, total, err
+}
+
+func (s *tracedUserService) SuspendUser(ctx context.Context, username, admin string) (model.User, error) {
+	ctx, done := startSpan(ctx, "UserService.SuspendUser")
+	res, err := s.next.SuspendUser(ctx, username, admin)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) ReactivateUser(ctx context.Context, username string) (model.User, error) {
+	ctx, done := startSpan(ctx, "UserService.ReactivateUser")
+	res, err := s.next.ReactivateUser(ctx, username)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) ForcePasswordReset(ctx context.Context, username string) error {
+	ctx, done := startSpan(ctx, "UserService.ForcePasswordReset")
+	err := s.next.ForcePasswordReset(ctx, username)
+	done(err)
+	return err
+}
+
+func (s *tracedUserService) CreateAdmin(ctx context.Context, user model.User) (model.User, error) {
+	ctx, done := startSpan(ctx, "UserService.CreateAdmin")
+	res, err := s.next.CreateAdmin(ctx, user)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) IssueAccessToken(ctx context.Context, username string) (string, error) {
+	ctx, done := startSpan(ctx, "UserService.IssueAccessToken")
+	res, err := s.next.IssueAccessToken(ctx, username)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) AuthenticateToken(ctx context.Context, userID int64, username string, tokenVersion int64) (model.Principal, error) {
+	ctx, done := startSpan(ctx, "UserService.AuthenticateToken")
+	res, err := s.next.AuthenticateToken(ctx, userID, username, tokenVersion)
+	done(err)
+	return res, err
+}
+
+func (s *tracedUserService) Logout(ctx context.Context) error {
+	ctx, done := startSpan(ctx, "UserService.Logout")
+	err := s.next.Logout(ctx)
+	done(err)
+	return err
+}
+
+func (s *tracedUserService) RequestPasswordReset(ctx context.Context, email string) error {
+	ctx, done := startSpan(ctx, "UserService.RequestPasswordReset")
+	err := s.next.RequestPasswordReset(ctx, email)
+	done(err)
+	return err
+}
+
+func (s *tracedUserService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	ctx, done := startSpan(ctx, "UserService.ResetPassword")
+	err := s.next.ResetPassword(ctx, token, newPassword)
+	done(err)
+	return err
+}
+
+func (s *tracedUserService) VerifyEmail(ctx context.Context, token string) error {
+	ctx, done := startSpan(ctx, "UserService.VerifyEmail")
+	err := s.next.VerifyEmail(ctx, token)
+	done(err)
+	return err
+}
+
+type tracedAPIKeyService struct {
+	next APIKeyService
+}
+
+// TraceAPIKeyService wraps next so that every call gets its own span.
+func TraceAPIKeyService(next APIKeyService) APIKeyService {
+	return &tracedAPIKeyService{next: next}
+}
+
+func (s *tracedAPIKeyService) CreateKey(ctx context.Context, username string, req model.CreateAPIKeyRequest) (model.CreatedAPIKey, error) {
+	ctx, done := startSpan(ctx, "APIKeyService.CreateKey")
+	res, err := s.next.CreateKey(ctx, username, req)
+	done(err)
+	return res, err
+}
+
+func (s *tracedAPIKeyService) ListKeys(ctx context.Context, username string) ([]model.APIKey, error) {
+	ctx, done := startSpan(ctx, "APIKeyService.ListKeys")
+	res, err := s.next.ListKeys(ctx, username)
+	done(err)
+	return res, err
+}
+
+func (s *tracedAPIKeyService) RevokeKey(ctx context.Context, username string, keyID int64) error {
+	ctx, done := startSpan(ctx, "APIKeyService.RevokeKey")
+	err := s.next.RevokeKey(ctx, username, keyID)
+	done(err)
+	return err
+}
+
+func (s *tracedAPIKeyService) Authenticate(ctx context.Context, rawKey string) (model.Principal, error) {
+	ctx, done := startSpan(ctx, "APIKeyService.Authenticate")
+	res, err := s.next.Authenticate(ctx, rawKey)
+	done(err)
+	return res, err
+}
+
+type tracedPrivacyService struct {
+	next PrivacyService
+}
+
+// TracePrivacyService wraps next so that every call gets its own span.
+func TracePrivacyService(next PrivacyService) PrivacyService {
+	return &tracedPrivacyService{next: next}
+}
+
+func (s *tracedPrivacyService) ExportUserData(ctx context.Context, username string) (model.UserDataExport, error) {
+	ctx, done := startSpan(ctx, "PrivacyService.ExportUserData")
+	res, err := s.next.ExportUserData(ctx, username)
+	done(err)
+	return res, err
+}
+
+func (s *tracedPrivacyService) RequestErasure(ctx context.Context, username string) (model.ErasureRequest, error) {
+	ctx, done := startSpan(ctx, "PrivacyService.RequestErasure")
+	res, err := s.next.RequestErasure(ctx, username)
+	done(err)
+	return res, err
+}
+
+func (s *tracedPrivacyService) ListErasureRequests(ctx context.Context, status string) ([]model.ErasureRequest, error) {
+	ctx, done := startSpan(ctx, "PrivacyService.ListErasureRequests")
+	res, err := s.next.ListErasureRequests(ctx, status)
+	done(err)
+	return res, err
+}
+
+func (s *tracedPrivacyService) ApproveErasure(ctx context.Context, id int64, admin string) (model.ErasureRequest, error) {
+	ctx, done := startSpan(ctx, "PrivacyService.ApproveErasure")
+	res, err := s.next.ApproveErasure(ctx, id, admin)
+	done(err)
+	return res, err
+}
+
+func (s *tracedPrivacyService) RejectErasure(ctx context.Context, id int64, admin string) (model.ErasureRequest, error) {
+	ctx, done := startSpan(ctx, "PrivacyService.RejectErasure")
+	res, err := s.next.RejectErasure(ctx, id, admin)
+	done(err)
+	return res, err
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"petstore/internal/apperror"
+	"petstore/internal/logging"
+	"petstore/internal/mailer"
+	"petstore/internal/model"
+	"time"
+)
+
+const (
+	passwordResetTTL = time.Hour
+	emailVerifyTTL   = 24 * time.Hour
+)
+
+var (
+	ErrInvalidToken         = apperror.Validation("invalid or expired token")
+	ErrEmailAlreadyVerified = apperror.Conflict("account is not awaiting email verification")
+)
+
+func (u *userService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := u.repo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Nothing to do, but the caller must not learn that.
+			return nil
+		}
+		return err
+	}
+
+	// Issue the token and send the mail in the background, so the response
+	// takes as long whether the account exists or not.
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		err := u.sendPasswordReset(ctx, user,
+			"Someone requested a password reset for your account.",
+			"If it wasn't you, you can ignore this message.")
+		if err != nil {
+			logging.FromContext(ctx).Error("sending password reset email failed", "username", user.Username, "err", err)
+		}
+	}()
+	return nil
+}
+
+func (u *userService) sendPasswordReset(ctx context.Context, user model.User, intro, outro string) error {
+	token, err := u.issueToken(ctx, user.ID, model.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\n%s\n"+
+		"Use this token within %s to choose a new password:\n\n%s\n\n",
+		user.Username, intro, passwordResetTTL, token)
+	if u.resetURL != "" {
+		body += withToken(u.resetURL, token) + "\n\n"
+	}
+	return u.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Petstore password",
+		Body:    body + outro + "\n",
+	})
+}
+
+func (u *userService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	// Check everything but the username before the token is spent, so that a
+	// weak password can simply be retried.
+	if err := u.passwords.Validate(newPassword, ""); err != nil {
+		return err
+	}
+
+	t, err := u.tokens.Consume(ctx, model.TokenPurposePasswordReset, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+
+	user, err := u.repo.FindByID(ctx, t.UserID)
+	if err != nil {
+		return err
+	}
+	if err := u.passwords.Validate(newPassword, user.Username); err != nil {
+		return err
+	}
+
+	hashed, err := u.passwords.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := u.repo.UpdatePassword(ctx, t.UserID, hashed); err != nil {
+		return err
+	}
+	if err := u.repo.RevokeAccessTokens(ctx, t.UserID); err != nil {
+		return err
+	}
+
+	return u.tokens.DeleteByUser(ctx, t.UserID, model.TokenPurposePasswordReset)
+}
+
+func (u *userService) VerifyEmail(ctx context.Context, token string) error {
+	t, err := u.tokens.Consume(ctx, model.TokenPurposeEmailVerify, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+
+	user, err := u.repo.FindByID(ctx, t.UserID)
+	if err != nil {
+		return err
+	}
+	// Only a pending signup is activated; the token must not lift a
+	// suspension.
+	if user.UserStatus != model.UserStatusUnverified {
+		return ErrEmailAlreadyVerified
+	}
+	return u.repo.UpdateStatus(ctx, t.UserID, model.UserStatusActive)
+}
+
+func (u *userService) sendVerificationEmail(ctx context.Context, user model.User) error {
+	token, err := u.issueToken(ctx, user.ID, model.TokenPurposeEmailVerify, emailVerifyTTL)
+	if err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Petstore email",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease confirm your email address by opening this link:\n\n%s\n\n"+
+			"The link is valid for %s.\n",
+			user.Username, u.link("/user/verify", token), emailVerifyTTL),
+	})
+}
+
+// issueToken replaces any outstanding token of the same purpose and returns
+// the raw value; only its hash is stored.
+func (u *userService) issueToken(ctx context.Context, userID int64, purpose string, ttl time.Duration) (string, error) {
+	raw, hash, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.tokens.DeleteByUser(ctx, userID, purpose); err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	_, err = u.tokens.Create(ctx, model.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return raw, nil
+}
+
+func (u *userService) link(path, token string) string {
+	return withToken(u.baseURL+path, token)
+}
+
+// withToken adds token to the query of rawURL, keeping any query it has.
+func withToken(rawURL, token string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL + "?token=" + url.QueryEscape(token)
+	}
+	query := parsed.Query()
+	query.Set("token", token)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"petstore/internal/apperror"
+	"petstore/internal/logging"
+	"petstore/internal/model"
+	"strings"
+)
+
+const (
+	DefaultUserPageSize = 20
+	MaxUserPageSize     = 100
+)
+
+var (
+	ErrAccountSuspended  = apperror.Forbidden("account is suspended")
+	ErrUserNotSuspended  = apperror.Conflict("user is not suspended")
+	ErrCannotSuspendSelf = apperror.Validation("admins cannot suspend their own account")
+	ErrUserHasNoEmail    = apperror.Conflict("user has no email address to send a reset link to")
+	ErrInvalidUserSearch = apperror.Validation("invalid user search")
+)
+
+func (u *userService) SearchUsers(ctx context.Context, search model.UserSearch) ([]model.User, int, error) {
+	if search.Limit == 0 {
+		search.Limit = DefaultUserPageSize
+	}
+	if search.Limit < 0 || search.Limit > MaxUserPageSize {
+		return nil, 0, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidUserSearch, MaxUserPageSize)
+	}
+	if search.Offset < 0 {
+		return nil, 0, fmt.Errorf("%w: offset cannot be negative", ErrInvalidUserSearch)
+	}
+	search.Query = strings.TrimSpace(search.Query)
+
+	return u.repo.Search(ctx, search)
+}
+
+// SuspendUser blocks all logins of the user. Access tokens issued before
+// the suspension are rejected from then on, see AuthenticateToken.
+func (u *userService) SuspendUser(ctx context.Context, username, admin string) (model.User, error) {
+	if strings.EqualFold(username, admin) {
+		return model.User{}, ErrCannotSuspendSelf
+	}
+
+	user, err := u.repo.FindByUsername(ctx, username)
+	if err != nil {
+		return model.User{}, err
+	}
+	if user.UserStatus == model.UserStatusErased {
+		return model.User{}, ErrUserErased
+	}
+
+	if err := u.repo.UpdateStatus(ctx, user.ID, model.UserStatusSuspended); err != nil {
+		return model.User{}, err
+	}
+	user.UserStatus = model.UserStatusSuspended
+	return user, nil
+}
+
+func (u *userService) ReactivateUser(ctx context.Context, username string) (model.User, error) {
+	user, err := u.repo.FindByUsername(ctx, username)
+	if err != nil {
+		return model.User{}, err
+	}
+	if user.UserStatus != model.UserStatusSuspended {
+		return model.User{}, ErrUserNotSuspended
+	}
+
+	if err := u.repo.UpdateStatus(ctx, user.ID, model.UserStatusActive); err != nil {
+		return model.User{}, err
+	}
+	user.UserStatus = model.UserStatusActive
+	return user, nil
+}
+
+// ForcePasswordReset invalidates the current password and access tokens and
+// mails the user a reset link, so the account can only be used again after
+// choosing a new password.
+func (u *userService) ForcePasswordReset(ctx context.Context, username string) error {
+	user, err := u.repo.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user.UserStatus == model.UserStatusErased {
+		return ErrUserErased
+	}
+	if user.Email == "" {
+		return ErrUserHasNoEmail
+	}
+
+	if err := u.repo.UpdatePassword(ctx, user.ID, ""); err != nil {
+		return err
+	}
+	if err := u.repo.RevokeAccessTokens(ctx, user.ID); err != nil {
+		return err
+	}
+	return u.sendPasswordReset(ctx, user,
+		"An administrator has reset the password of your account. Your old password no longer works.",
+		"Contact support if you did not expect this.")
+}
+
+// CreateAdmin creates an active admin account. It is meant for operators
+// bootstrapping a deployment, not for the HTTP API.
+func (u *userService) CreateAdmin(ctx context.Context, user model.User) (model.User, error) {
+	if err := u.passwords.Validate(user.Password, user.Username); err != nil {
+		return model.User{}, err
+	}
+	hashedPassword, err := u.passwords.Hash(user.Password)
+	if err != nil {
+		return model.User{}, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user.Password = hashedPassword
+	user.UserStatus = model.UserStatusActive
+	user.Role = model.RoleAdmin
+
+	return u.repo.Create(ctx, user)
+}
+
+// IssueAccessToken issues an access token for the user without a login, for
+// operators and scripts. The login time is not recorded.
+func (u *userService) IssueAccessToken(ctx context.Context, username string) (string, error) {
+	user, err := u.repo.FindByUsername(ctx, username)
+	if err != nil {
+		return "", err
+	}
+	if err := checkLoginAllowed(user); err != nil {
+		return "", err
+	}
+	return u.issueAccessToken(user)
+}
+
+// checkLoginAllowed rejects accounts that may not log in regardless of the
+// credentials presented.
+func checkLoginAllowed(user model.User) error {
+	switch user.UserStatus {
+	case model.UserStatusSuspended:
+		return ErrAccountSuspended
+	case model.UserStatusErased:
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// finishLogin records the login time and issues the access token.
+func (u *userService) finishLogin(ctx context.Context, user model.User) (string, error) {
+	if err := u.repo.UpdateLastLogin(ctx, user.ID); err != nil {
+		logging.FromContext(ctx).Warn("recording login failed", "username", user.Username, "err", err)
+	}
+	return u.issueAccessToken(user)
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"petstore/internal/apperror"
+	"petstore/internal/logging"
+	"petstore/internal/model"
+	"petstore/internal/repository"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	BatchModeAtomic  = "atomic"
+	BatchModePartial = "partial"
+)
+
+// ErrBatchRejected is returned in atomic mode when at least one item is
+// invalid; the per-item results say which ones.
+var ErrBatchRejected = apperror.Validation("batch rejected: one or more users are invalid")
+
+type UserBatchItem struct {
+	Index int
+	User  model.User
+	Err   error
+}
+
+// CreateUserBatch validates and hashes every user and stores them in a single
+// transaction. In atomic mode nothing is stored unless every user is valid
+// and inserted; in partial mode valid users are stored and failures are
+// reported per item. Items that arrive with Err set, e.g. from request
+// validation, count as invalid.
+func (u *userService) CreateUserBatch(ctx context.Context, items []UserBatchItem, mode string) ([]UserBatchItem, error) {
+	if mode == "" {
+		mode = BatchModeAtomic
+	}
+	if mode != BatchModeAtomic && mode != BatchModePartial {
+		return nil, fmt.Errorf("unknown batch mode %q", mode)
+	}
+
+	usernames := make([]string, 0, len(items))
+	emails := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	seenEmails := make(map[string]bool, len(items))
+	for i := range items {
+		user := &items[i].User
+		user.UserStatus = model.UserStatusUnverified
+		user.Role = model.RoleCustomer
+
+		if items[i].Err != nil {
+			continue
+		}
+		if err := u.passwords.Validate(user.Password, user.Username); err != nil {
+			items[i].Err = err
+			continue
+		}
+		key := strings.ToLower(user.Username)
+		if seen[key] {
+			items[i].Err = fmt.Errorf("duplicate username %q in batch", user.Username)
+			continue
+		}
+		emailKey := strings.ToLower(user.Email)
+		if emailKey != "" && seenEmails[emailKey] {
+			items[i].Err = fmt.Errorf("duplicate email %q in batch", user.Email)
+			continue
+		}
+		seen[key] = true
+		usernames = append(usernames, user.Username)
+		if emailKey != "" {
+			seenEmails[emailKey] = true
+			emails = append(emails, user.Email)
+		}
+	}
+
+	existing, err := u.repo.ExistingUsernames(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+	existingEmails, err := u.repo.ExistingEmails(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		user := items[i].User
+		switch {
+		case items[i].Err != nil:
+		case existing[strings.ToLower(user.Username)]:
+			items[i].Err = fmt.Errorf("username %q already exists", user.Username)
+		case user.Email != "" && existingEmails[strings.ToLower(user.Email)]:
+			items[i].Err = fmt.Errorf("email %q already exists", user.Email)
+		}
+	}
+
+	valid := make([]int, 0, len(items))
+	for i := range items {
+		if items[i].Err == nil {
+			valid = append(valid, i)
+		}
+	}
+	if mode == BatchModeAtomic && len(valid) != len(items) {
+		return items, ErrBatchRejected
+	}
+	if len(valid) == 0 {
+		return items, nil
+	}
+
+	if err := u.hashBatchPasswords(items, valid); err != nil {
+		return nil, err
+	}
+	toCreate := make([]model.User, 0, len(valid))
+	for _, i := range valid {
+		toCreate = append(toCreate, items[i].User)
+	}
+
+	if mode == BatchModeAtomic {
+		created, err := u.repo.CreateBatch(ctx, toCreate)
+		if err != nil {
+			return nil, err
+		}
+		for n, i := range valid {
+			items[i].User = created[n]
+		}
+	} else {
+		created, errs, err := u.repo.CreateEach(ctx, toCreate)
+		if err != nil {
+			return nil, err
+		}
+		for n, i := range valid {
+			items[i].User, items[i].Err = created[n], batchItemError(ctx, items[i].User, errs[n])
+		}
+	}
+
+	for _, item := range items {
+		if item.Err == nil && item.User.Email != "" {
+			if err := u.sendVerificationEmail(ctx, item.User); err != nil {
+				logging.FromContext(ctx).Error("sending verification email failed", "username", item.User.Username, "err", err)
+			}
+		}
+	}
+
+	return items, nil
+}
+
+// batchItemError turns the error of inserting one row into what is reported
+// for the item. Conflicts, e.g. with a user created since the checks above,
+// are reported like the checks do; other errors are logged and reported
+// without the repository details.
+func batchItemError(ctx context.Context, user model.User, err error) error {
+	if err == nil {
+		return nil
+	}
+	var conflict *repository.ConflictError
+	if errors.As(err, &conflict) {
+		if conflict.Value == "" {
+			return fmt.Errorf("%s already exists", conflict.Field)
+		}
+		return fmt.Errorf("%s %q already exists", conflict.Field, conflict.Value)
+	}
+	logging.FromContext(ctx).Error("creating user in batch failed", "username", user.Username, "err", err)
+	return errors.New("failed to create user")
+}
+
+// hashBatchPasswords hashes the selected items' passwords in parallel, since
+// hashing dominates the cost of a large import.
+func (u *userService) hashBatchPasswords(items []UserBatchItem, indexes []int) error {
+	jobs := make(chan int)
+	errs := make(chan error, len(indexes))
+
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				hashed, err := u.passwords.Hash(items[i].User.Password)
+				if err != nil {
+					errs <- fmt.Errorf("failed to hash password: %w", err)
+					continue
+				}
+				items[i].User.Password = hashed
+			}
+		}()
+	}
+	for _, i := range indexes {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"petstore/internal/metrics"
+	"petstore/internal/model"
+	"strings"
+)
+
+// LoginExternal signs in a user authenticated by an external identity
+// provider, creating the account on first login. The provider is treated as
+// authoritative for name, email and role, which are refreshed every time.
+func (u *userService) LoginExternal(ctx context.Context, identity model.ExternalIdentity) (string, error) {
+	if identity.Provider == "" || identity.Subject == "" {
+		return "", fmt.Errorf("external identity is missing provider or subject")
+	}
+	if identity.Role == "" {
+		identity.Role = model.RoleCustomer
+	}
+
+	user, err := u.findExternalUser(ctx, identity)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return "", err
+		}
+		user, err = u.provisionExternalUser(ctx, identity)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		if err := checkLoginAllowed(user); err != nil {
+			u.metrics.LoginFailed(metrics.LoginNotAllowed)
+			return "", err
+		}
+		if err := u.repo.UpdateExternalIdentity(ctx, user.ID, identity); err != nil {
+			return "", err
+		}
+		user.Role = identity.Role
+	}
+
+	return u.finishLogin(ctx, user)
+}
+
+// findExternalUser looks the user up by provider subject and falls back to a
+// verified email, which links an existing local account on its first SSO login.
+func (u *userService) findExternalUser(ctx context.Context, identity model.ExternalIdentity) (model.User, error) {
+	user, err := u.repo.FindByExternalIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return user, err
+	}
+	if !identity.EmailVerified || identity.Email == "" {
+		return model.User{}, sql.ErrNoRows
+	}
+
+	user, err = u.repo.FindByEmail(ctx, identity.Email)
+	if err != nil {
+		return model.User{}, err
+	}
+	if user.ExternalSubject != "" {
+		// Already bound to a different subject; don't take it over.
+		return model.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (u *userService) provisionExternalUser(ctx context.Context, identity model.ExternalIdentity) (model.User, error) {
+	username, err := u.availableUsername(ctx, externalUsername(identity))
+	if err != nil {
+		return model.User{}, err
+	}
+
+	return u.repo.Create(ctx, model.User{
+		Username:        username,
+		FirstName:       identity.FirstName,
+		LastName:        identity.LastName,
+		Email:           identity.Email,
+		UserStatus:      model.UserStatusActive,
+		Role:            identity.Role,
+		AuthProvider:    identity.Provider,
+		ExternalSubject: identity.Subject,
+	})
+}
+
+func (u *userService) availableUsername(ctx context.Context, base string) (string, error) {
+	candidate := base
+	for i := 2; i <= 100; i++ {
+		_, err := u.repo.FindByUsername(ctx, candidate)
+		if errors.Is(err, sql.ErrNoRows) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+	return "", fmt.Errorf("no free username for %q", base)
+}
+
+func externalUsername(identity model.ExternalIdentity) string {
+	name := identity.Provider + "-" + identity.Subject
+	if identity.Username != "" {
+		name = identity.Username
+	} else if at := strings.Index(identity.Email, "@"); at > 0 {
+		name = identity.Email[:at]
+	}
+	if model.IsReservedUsername(name) {
+		return identity.Provider + "-" + name
+	}
+	return name
+}
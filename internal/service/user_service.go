@@ -2,69 +2,232 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"petstore/internal/apperror"
 	"petstore/internal/config"
+	"petstore/internal/logging"
+	"petstore/internal/mailer"
+	"petstore/internal/metrics"
 	"petstore/internal/model"
 	"petstore/internal/repository"
+	"strings"
+
+	"github.com/go-chi/jwtauth"
+)
+
+var (
+	ErrInvalidCredentials = apperror.Unauthorized("invalid credentials")
+	ErrInvalidAccessToken = apperror.Unauthorized("invalid access token")
 )
 
 type UserService interface {
 	CreateUser(ctx context.Context, user model.User) (model.User, error)
-	CreateUserBatch(ctx context.Context, users []model.User) ([]model.User, error)
+	CreateUserBatch(ctx context.Context, items []UserBatchItem, mode string) ([]UserBatchItem, error)
 	FindUserByUsername(ctx context.Context, username string) (model.User, error)
 	UpdateUser(ctx context.Context, username string, user model.User) (model.User, error)
 	DeleteUser(ctx context.Context, username string) error
-	Login(ctx context.Context, username, password string) (string, error)
+	Login(ctx context.Context, username, password, clientIP string) (model.LoginResult, error)
+	CompleteTwoFactorLogin(ctx context.Context, challengeToken, code, clientIP string) (string, error)
+	EnrollTOTP(ctx context.Context, username string) (model.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, username, code string) ([]string, error)
+	LoginExternal(ctx context.Context, identity model.ExternalIdentity) (string, error)
+	SearchUsers(ctx context.Context, search model.UserSearch) ([]model.User, int, error)
+	SuspendUser(ctx context.Context, username, admin string) (model.User, error)
+	ReactivateUser(ctx context.Context, username string) (model.User, error)
+	ForcePasswordReset(ctx context.Context, username string) error
+	CreateAdmin(ctx context.Context, user model.User) (model.User, error)
+	IssueAccessToken(ctx context.Context, username string) (string, error)
+	AuthenticateToken(ctx context.Context, userID int64, username string, tokenVersion int64) (model.Principal, error)
 	Logout(ctx context.Context) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	VerifyEmail(ctx context.Context, token string) error
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo      repository.UserRepository
+	tokens    repository.TokenRepository
+	mailer    mailer.Mailer
+	throttler *LoginThrottler
+	passwords *PasswordManager
+	metrics   *metrics.Metrics
+	baseURL   string
+	resetURL  string
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo: repo}
+func NewUserService(
+	repo repository.UserRepository,
+	tokens repository.TokenRepository,
+	mail mailer.Mailer,
+	throttler *LoginThrottler,
+	passwords *PasswordManager,
+	m *metrics.Metrics,
+	baseURL string,
+	resetURL string,
+) UserService {
+	return &userService{
+		repo:      repo,
+		tokens:    tokens,
+		mailer:    mail,
+		throttler: throttler,
+		passwords: passwords,
+		metrics:   m,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		resetURL:  resetURL,
+	}
 }
 
 func (u *userService) CreateUser(ctx context.Context, user model.User) (model.User, error) {
-	hashedPassword, err := hashPassword(user.Password)
+	if err := u.passwords.Validate(user.Password, user.Username); err != nil {
+		return model.User{}, err
+	}
+	hashedPassword, err := u.passwords.Hash(user.Password)
 	if err != nil {
 		return model.User{}, fmt.Errorf("failed to hash password: %w", err)
 	}
 
 	user.Password = hashedPassword
+	user.UserStatus = model.UserStatusUnverified
+	user.Role = model.RoleCustomer
 
-	return u.repo.Create(ctx, user)
-}
+	created, err := u.repo.Create(ctx, user)
+	if err != nil {
+		return model.User{}, err
+	}
 
-func (u *userService) CreateUserBatch(ctx context.Context, users []model.User) ([]model.User, error) {
-	return u.repo.CreateBatch(ctx, users)
+	if created.Email != "" {
+		if err := u.sendVerificationEmail(ctx, created); err != nil {
+			logging.FromContext(ctx).Error("sending verification email failed", "username", created.Username, "err", err)
+		}
+	}
+
+	return created, nil
 }
 
 func (u *userService) FindUserByUsername(ctx context.Context, username string) (model.User, error) {
 	return u.repo.FindByUsername(ctx, username)
 }
 
+// UpdateUser keeps the stored password hash unless a new password is given.
 func (u *userService) UpdateUser(ctx context.Context, username string, user model.User) (model.User, error) {
-	return u.repo.Update(ctx, username, user)
+	existing, err := u.repo.FindByUsername(ctx, username)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if user.Password == "" {
+		user.Password = existing.Password
+	} else {
+		if err := u.passwords.Validate(user.Password, existing.Username); err != nil {
+			return model.User{}, err
+		}
+		hashedPassword, err := u.passwords.Hash(user.Password)
+		if err != nil {
+			return model.User{}, fmt.Errorf("failed to hash password: %w", err)
+		}
+		user.Password = hashedPassword
+	}
+	user.UserStatus = existing.UserStatus
+
+	updated, err := u.repo.Update(ctx, username, user)
+	if err != nil {
+		return model.User{}, err
+	}
+	if updated.Password != existing.Password {
+		if err := u.repo.RevokeAccessTokens(ctx, existing.ID); err != nil {
+			return model.User{}, err
+		}
+	}
+	return updated, nil
+}
+
+// rehashIfNeeded upgrades a stored hash after the hash configuration has
+// changed. Failures are logged only, the login itself already succeeded.
+func (u *userService) rehashIfNeeded(ctx context.Context, user model.User, password string) {
+	if !u.passwords.NeedsRehash(user.Password) {
+		return
+	}
+	hashed, err := u.passwords.Hash(password)
+	if err == nil {
+		err = u.repo.UpdatePassword(ctx, user.ID, hashed)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("rehashing password failed", "username", user.Username, "err", err)
+	}
 }
 
 func (u *userService) DeleteUser(ctx context.Context, username string) error {
 	return u.repo.Delete(ctx, username)
 }
 
-func (u *userService) Login(ctx context.Context, username, password string) (string, error) {
+func (u *userService) Login(ctx context.Context, username, password, clientIP string) (model.LoginResult, error) {
+	throttleKey := strings.ToLower(username)
+	attempt, err := u.throttler.Check(throttleKey, clientIP)
+	if err != nil {
+		u.metrics.LoginFailed(metrics.LoginThrottled)
+		return model.LoginResult{}, err
+	}
+	defer attempt.Done()
+
 	user, err := u.repo.FindByUsername(ctx, username)
 	if err != nil {
-		return "", fmt.Errorf("user with username %s not found: %w", username, err)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return model.LoginResult{}, fmt.Errorf("failed to find user: %w", err)
+		}
+		// Spend the same hashing time as for an existing user so the response
+		// time does not reveal whether the username exists.
+		_ = u.passwords.Verify(u.passwords.DummyHash(), password)
+		attempt.Fail()
+		u.metrics.LoginFailed(metrics.LoginInvalidCredentials)
+		return model.LoginResult{}, ErrInvalidCredentials
 	}
-	if err := checkPasswordHash(user.Password, password); err != nil {
-		return "", fmt.Errorf("invalid credentials")
+	if err := u.passwords.Verify(user.Password, password); err != nil {
+		attempt.Fail()
+		u.metrics.LoginFailed(metrics.LoginInvalidCredentials)
+		return model.LoginResult{}, ErrInvalidCredentials
 	}
+	u.rehashIfNeeded(ctx, user, password)
+	// Checked only after the password, so the status is not revealed to
+	// someone guessing.
+	if err := checkLoginAllowed(user); err != nil {
+		u.metrics.LoginFailed(metrics.LoginNotAllowed)
+		return model.LoginResult{}, err
+	}
+
+	if user.TOTPEnabled {
+		// The throttle is only reset once the second factor is verified too.
+		challenge, err := u.issueChallengeToken(user)
+		if err != nil {
+			return model.LoginResult{}, err
+		}
+		return model.LoginResult{TwoFactorRequired: true, ChallengeToken: challenge}, nil
+	}
+	attempt.Succeed()
 
-	_, token, err := config.TokenAuth.Encode(map[string]interface{}{
+	token, err := u.finishLogin(ctx, user)
+	if err != nil {
+		return model.LoginResult{}, err
+	}
+
+	return model.LoginResult{Token: token}, nil
+}
+
+// issueAccessToken signs a token for the user that expires after
+// config.AccessTokenTTL. The role claim is informational; requests are
+// authorized with the role stored for the user, see AuthenticateToken.
+func (u *userService) issueAccessToken(user model.User) (string, error) {
+	claims := map[string]interface{}{
+		"uid":      user.ID,
 		"username": user.Username,
-	})
+		"role":     user.Role,
+		"ver":      user.TokenVersion,
+	}
+	jwtauth.SetIssuedNow(claims)
+	jwtauth.SetExpiryIn(claims, config.AccessTokenTTL)
+
+	_, token, err := config.TokenAuth.Encode(claims)
 	if err != nil {
 		return "", fmt.Errorf("failed generating token: %w", err)
 	}
@@ -72,6 +235,26 @@ func (u *userService) Login(ctx context.Context, username, password string) (str
 	return token, nil
 }
 
-func(u *userService)Logout(ctx context.Context) error{
+// AuthenticateToken resolves the principal of an access token issued for the
+// user. The user is loaded on every request so that suspended, erased and
+// deleted accounts lose access at once and role changes apply immediately.
+// Tokens issued before the last password reset have an older version and
+// are rejected too.
+func (u *userService) AuthenticateToken(ctx context.Context, userID int64, username string, tokenVersion int64) (model.Principal, error) {
+	user, err := u.repo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Principal{}, ErrInvalidAccessToken
+		}
+		return model.Principal{}, err
+	}
+	// An erased user keeps the ID under a new name.
+	if user.Username != username || user.TokenVersion != tokenVersion || checkLoginAllowed(user) != nil {
+		return model.Principal{}, ErrInvalidAccessToken
+	}
+	return model.Principal{Username: user.Username, Role: user.Role}, nil
+}
+
+func (u *userService) Logout(ctx context.Context) error {
 	return nil
 }
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"petstore/internal/config"
+	"petstore/internal/mailer"
+	"petstore/internal/metrics"
+	"petstore/internal/repository/memory"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	testUsername = "jane"
+	testPassword = "correct-horse-battery"
+)
+
+// newTestUserService returns a user service on memory storage that mails to
+// an outbox in a temporary directory.
+func newTestUserService(t *testing.T) *userService {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.JWT.Secret = "service-test-secret-0123456789abcdef"
+	cfg.Mail.Driver = config.MailDriverOutbox
+	cfg.Mail.OutboxDir = t.TempDir()
+	cfg.Password.CheckBreached = false
+	cfg.Password.BcryptCost = bcrypt.MinCost
+	config.InitJWT(cfg.JWT)
+
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwords, err := NewPasswordManager(cfg.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := memory.NewStore()
+	return NewUserService(memory.NewUserRepository(store), memory.NewTokenRepository(store), mail,
+		NewLoginThrottler(DefaultLoginThrottleConfig), passwords, metrics.New(),
+		cfg.Mail.AppBaseURL, "").(*userService)
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"petstore/internal/apperror"
+	"petstore/internal/config"
+	"petstore/internal/metrics"
+	"petstore/internal/model"
+	"strings"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+)
+
+const (
+	challengeTTL      = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTOTPAlreadyEnabled = apperror.Conflict("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled    = apperror.Conflict("two-factor enrollment has not been started")
+	ErrInvalidTOTPCode    = apperror.Unauthorized("invalid two-factor code")
+	// ErrInvalidConfirmationCode rejects the first code during enrollment,
+	// where the caller is already authenticated.
+	ErrInvalidConfirmationCode = apperror.Validation("invalid two-factor code")
+)
+
+func (u *userService) EnrollTOTP(ctx context.Context, username string) (model.TOTPEnrollment, error) {
+	user, err := u.repo.FindByUsername(ctx, username)
+	if err != nil {
+		return model.TOTPEnrollment{}, err
+	}
+	if user.TOTPEnabled {
+		return model.TOTPEnrollment{}, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return model.TOTPEnrollment{}, err
+	}
+	if err := u.repo.UpdateTOTP(ctx, user.ID, secret, false); err != nil {
+		return model.TOTPEnrollment{}, err
+	}
+
+	return model.TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totpURI(user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once the user proves the
+// authenticator is set up, and returns freshly generated recovery codes.
+// The codes are shown only once; just their hashes are stored.
+func (u *userService) ConfirmTOTP(ctx context.Context, username, code string) ([]string, error) {
+	user, err := u.repo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTOTPNotEnrolled
+	}
+	ok, err := u.acceptTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidConfirmationCode
+	}
+
+	if err := u.tokens.DeleteByUser(ctx, user.ID, model.TokenPurposeRecoveryCode); err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		_, err = u.tokens.Create(ctx, model.UserToken{
+			UserID:    user.ID,
+			Purpose:   model.TokenPurposeRecoveryCode,
+			TokenHash: hashToken(normalizeRecoveryCode(code)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	if err := u.repo.UpdateTOTP(ctx, user.ID, user.TOTPSecret, true); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// CompleteTwoFactorLogin exchanges a challenge token from Login and a TOTP or
+// recovery code for an access token. Failures count towards the same login
+// throttle as wrong passwords.
+func (u *userService) CompleteTwoFactorLogin(ctx context.Context, challengeToken, code, clientIP string) (string, error) {
+	token, err := jwtauth.VerifyToken(config.ChallengeAuth, challengeToken)
+	if err != nil {
+		return "", ErrInvalidCredentials
+	}
+	username, _ := token.PrivateClaims()["username"].(string)
+	if username == "" {
+		return "", ErrInvalidCredentials
+	}
+
+	throttleKey := strings.ToLower(username)
+	attempt, err := u.throttler.Check(throttleKey, clientIP)
+	if err != nil {
+		u.metrics.LoginFailed(metrics.LoginThrottled)
+		return "", err
+	}
+	defer attempt.Done()
+
+	user, err := u.repo.FindByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrInvalidCredentials
+		}
+		return "", fmt.Errorf("failed to find user: %w", err)
+	}
+	if !user.TOTPEnabled {
+		return "", ErrInvalidCredentials
+	}
+	if err := checkLoginAllowed(user); err != nil {
+		u.metrics.LoginFailed(metrics.LoginNotAllowed)
+		return "", err
+	}
+
+	ok, err := u.checkSecondFactor(ctx, user, code)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		attempt.Fail()
+		u.metrics.LoginFailed(metrics.LoginInvalidTOTP)
+		return "", ErrInvalidTOTPCode
+	}
+	attempt.Succeed()
+
+	return u.finishLogin(ctx, user)
+}
+
+func (u *userService) checkSecondFactor(ctx context.Context, user model.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		return u.acceptTOTP(ctx, user, code)
+	}
+
+	_, err := u.tokens.ConsumeForUser(ctx, user.ID, model.TokenPurposeRecoveryCode,
+		hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// acceptTOTP checks a TOTP code and spends its time step, so that an
+// intercepted code can't be replayed while it is still valid.
+func (u *userService) acceptTOTP(ctx context.Context, user model.User, code string) (bool, error) {
+	step, ok := matchTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return u.repo.UseTOTPStep(ctx, user.ID, step)
+}
+
+func (u *userService) issueChallengeToken(user model.User) (string, error) {
+	claims := map[string]interface{}{
+		"username": user.Username,
+	}
+	jwtauth.SetExpiryIn(claims, challengeTTL)
+
+	_, token, err := config.ChallengeAuth.Encode(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed generating challenge token: %w", err)
+	}
+	return token, nil
+}
+
+// newRecoveryCode returns a code like "k3f9q-x7m2p".
+func newRecoveryCode() (string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	// Bytes at or above this limit are drawn again, so that every letter is
+	// equally likely.
+	const limit = 256 - 256%len(alphabet)
+
+	code := make([]byte, 0, 10)
+	buf := make([]byte, 16)
+	for len(code) < cap(code) {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(code) < cap(code) {
+				code = append(code, alphabet[int(b)%len(alphabet)])
+			}
+		}
+	}
+	return string(code[:5]) + "-" + string(code[5:]), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"petstore/internal/model"
+	"testing"
+	"time"
+)
+
+// enableTOTP signs up the test user with two-factor authentication and
+// returns the TOTP key and the recovery codes. Confirming the enrollment
+// spends the TOTP step of now.
+func enableTOTP(t *testing.T, u *userService, now time.Time) ([]byte, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := u.CreateUser(ctx, model.User{Username: testUsername, Password: testPassword}); err != nil {
+		t.Fatal(err)
+	}
+	enrollment, err := u.EnrollTOTP(ctx, testUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(enrollment.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, err := u.ConfirmTOTP(ctx, testUsername, hotp(key, uint64(now.Unix()/totpPeriod)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, codes
+}
+
+// loginWithCode logs the test user in with the second factor code.
+func loginWithCode(t *testing.T, u *userService, code string) error {
+	t.Helper()
+	ctx := context.Background()
+
+	result, err := u.Login(ctx, testUsername, testPassword, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.TwoFactorRequired {
+		t.Fatal("login did not ask for a second factor")
+	}
+	_, err = u.CompleteTwoFactorLogin(ctx, result.ChallengeToken, code, "")
+	return err
+}
+
+func TestTwoFactorLoginRejectsReplayedCode(t *testing.T) {
+	u := newTestUserService(t)
+	now := time.Now()
+	key, _ := enableTOTP(t, u, now)
+
+	confirmation := hotp(key, uint64(now.Unix()/totpPeriod))
+	if err := loginWithCode(t, u, confirmation); !errors.Is(err, ErrInvalidTOTPCode) {
+		t.Errorf("login with the confirmation code: got %v, want %v", err, ErrInvalidTOTPCode)
+	}
+
+	// The next step is within the drift window and not spent yet.
+	next := hotp(key, uint64(now.Unix()/totpPeriod+1))
+	if err := loginWithCode(t, u, next); err != nil {
+		t.Fatalf("login with a fresh code: %v", err)
+	}
+	if err := loginWithCode(t, u, next); !errors.Is(err, ErrInvalidTOTPCode) {
+		t.Errorf("login with the same code again: got %v, want %v", err, ErrInvalidTOTPCode)
+	}
+}
+
+func TestTwoFactorLoginAcceptsRecoveryCodeOnce(t *testing.T) {
+	u := newTestUserService(t)
+	_, codes := enableTOTP(t, u, time.Now())
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	if err := loginWithCode(t, u, codes[0]); err != nil {
+		t.Fatalf("login with a recovery code: %v", err)
+	}
+	if err := loginWithCode(t, u, codes[0]); !errors.Is(err, ErrInvalidTOTPCode) {
+		t.Errorf("login with a used recovery code: got %v, want %v", err, ErrInvalidTOTPCode)
+	}
+	if err := loginWithCode(t, u, codes[1]); err != nil {
+		t.Errorf("login with another recovery code: %v", err)
+	}
+}
@@ -0,0 +1,305 @@
+// Package validate checks request types against rules declared in their
+// `validate` struct tags, for example
+//
+//	Name      string   `json:"name" validate:"required,max=255"`
+//	PhotoUrls []string `json:"photoUrls" validate:"max=20,dive,url"`
+//
+// Every broken rule is reported as an apperror.FieldError named after the
+// field's JSON key, so clients get all problems of a request at once.
+//
+// Rules other than required are skipped for zero values. Supported rules:
+// required, min=N and max=N (length of strings and slices, value of
+// numbers), oneof=a b c, noprefix=p (case-insensitive), url, email, phone,
+// future (time.Time) and dive, which applies the rules after it to each
+// element of a slice. Nested structs and slices of structs are checked
+// recursively.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"petstore/internal/apperror"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	phoneRe  = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+)
+
+// Struct validates v, a struct or a slice of structs. It returns a
+// validation error listing every invalid field, or nil.
+func Struct(v interface{}) error {
+	var errs apperror.FieldErrors
+	if err := walk(reflect.ValueOf(v), "", &errs); err != nil {
+		return err
+	}
+	return errs.Err()
+}
+
+func walk(v reflect.Value, path string, errs *apperror.FieldErrors) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return nil
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			fieldPath := path
+			if !f.Anonymous {
+				name := jsonName(f)
+				if name == "-" {
+					continue
+				}
+				fieldPath = joinPath(path, name)
+			}
+			if tag := f.Tag.Get("validate"); tag != "" {
+				if err := apply(v.Field(i), fieldPath, strings.Split(tag, ","), errs); err != nil {
+					return err
+				}
+			}
+			if err := walk(v.Field(i), fieldPath, errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// apply checks one value against its rules and records at most one error
+// for it, so a missing field isn't also reported as too short.
+func apply(v reflect.Value, path string, rules []string, errs *apperror.FieldErrors) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			break
+		}
+		v = v.Elem()
+	}
+
+	for i, rule := range rules {
+		if rule == "dive" {
+			if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+				return fmt.Errorf("validate: dive on non-slice field %s", path)
+			}
+			for j := 0; j < v.Len(); j++ {
+				if err := apply(v.Index(j), fmt.Sprintf("%s[%d]", path, j), rules[i+1:], errs); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if rule == "required" && v.IsZero() {
+			errs.Add(path, apperror.CodeRequired, "%s is required", path)
+			return nil
+		}
+	}
+	if v.IsZero() {
+		return nil
+	}
+
+	for _, rule := range rules {
+		if rule == "dive" {
+			break
+		}
+		name, param := rule, ""
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+		check, ok := checks[name]
+		if !ok {
+			return fmt.Errorf("validate: unknown rule %q on field %s", name, path)
+		}
+		fe, err := check(v, param)
+		if err != nil {
+			return fmt.Errorf("validate: rule %q on field %s: %w", rule, path, err)
+		}
+		if fe != nil {
+			fe.Field = path
+			fe.Message = path + " " + fe.Message
+			*errs = append(*errs, *fe)
+			return nil
+		}
+	}
+	return nil
+}
+
+// A check returns a FieldError without Field when v breaks the rule. The
+// message is completed with the field path.
+type check func(v reflect.Value, param string) (*apperror.FieldError, error)
+
+var checks = map[string]check{
+	"required": func(reflect.Value, string) (*apperror.FieldError, error) { return nil, nil },
+	"min":      checkMin,
+	"max":      checkMax,
+	"oneof":    checkOneOf,
+	"noprefix": checkNoPrefix,
+	"url":      checkURL,
+	"email":    checkEmail,
+	"phone":    checkPhone,
+	"future":   checkFuture,
+}
+
+func checkMin(v reflect.Value, param string) (*apperror.FieldError, error) {
+	return checkBound(v, param, false)
+}
+
+func checkMax(v reflect.Value, param string) (*apperror.FieldError, error) {
+	return checkBound(v, param, true)
+}
+
+func checkBound(v reflect.Value, param string, max bool) (*apperror.FieldError, error) {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return nil, err
+	}
+	limit := "at least"
+	if max {
+		limit = "at most"
+	}
+	broken := func(n float64) bool {
+		if max {
+			return n > bound
+		}
+		return n < bound
+	}
+	lengthCode := apperror.CodeTooShort
+	if max {
+		lengthCode = apperror.CodeTooLong
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if broken(float64(utf8.RuneCountInString(v.String()))) {
+			return fieldError(lengthCode, "must be %s %s characters", limit, param), nil
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if broken(float64(v.Len())) {
+			return fieldError(lengthCode, "must have %s %s items", limit, param), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if broken(float64(v.Int())) {
+			return fieldError(apperror.CodeOutOfRange, "must be %s %s", limit, param), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if broken(float64(v.Uint())) {
+			return fieldError(apperror.CodeOutOfRange, "must be %s %s", limit, param), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if broken(v.Float()) {
+			return fieldError(apperror.CodeOutOfRange, "must be %s %s", limit, param), nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+	return nil, nil
+}
+
+func checkOneOf(v reflect.Value, param string) (*apperror.FieldError, error) {
+	if v.Kind() != reflect.String {
+		return nil, fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+	allowed := strings.Fields(param)
+	for _, a := range allowed {
+		if v.String() == a {
+			return nil, nil
+		}
+	}
+	return fieldError(apperror.CodeNotAllowed, "must be one of %s", strings.Join(allowed, ", ")), nil
+}
+
+func checkNoPrefix(v reflect.Value, param string) (*apperror.FieldError, error) {
+	if v.Kind() != reflect.String {
+		return nil, fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+	if strings.HasPrefix(strings.ToLower(v.String()), strings.ToLower(param)) {
+		return fieldError(apperror.CodeNotAllowed, "must not start with %q", param), nil
+	}
+	return nil, nil
+}
+
+func checkURL(v reflect.Value, _ string) (*apperror.FieldError, error) {
+	if v.Kind() != reflect.String {
+		return nil, fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+	u, err := url.Parse(v.String())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fieldError(apperror.CodeInvalidFormat, "must be an absolute http or https URL"), nil
+	}
+	return nil, nil
+}
+
+func checkEmail(v reflect.Value, _ string) (*apperror.FieldError, error) {
+	if v.Kind() != reflect.String {
+		return nil, fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+	// ParseAddress also accepts "Name <addr>"; only a bare address is valid here.
+	addr, err := mail.ParseAddress(v.String())
+	if err != nil || addr.Address != v.String() {
+		return fieldError(apperror.CodeInvalidFormat, "must be a valid email address"), nil
+	}
+	return nil, nil
+}
+
+func checkPhone(v reflect.Value, _ string) (*apperror.FieldError, error) {
+	if v.Kind() != reflect.String {
+		return nil, fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+	digits := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(v.String())
+	if !phoneRe.MatchString(digits) {
+		return fieldError(apperror.CodeInvalidFormat, "must be a phone number of 7 to 15 digits, optionally starting with +"), nil
+	}
+	return nil, nil
+}
+
+func checkFuture(v reflect.Value, _ string) (*apperror.FieldError, error) {
+	t, ok := v.Interface().(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %s", v.Type())
+	}
+	if !t.After(time.Now()) {
+		return fieldError(apperror.CodeNotInFuture, "must be in the future"), nil
+	}
+	return nil, nil
+}
+
+func fieldError(code, format string, args ...interface{}) *apperror.FieldError {
+	return &apperror.FieldError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func jsonName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS user_tokens;
\ No newline at end of file
@@ -0,0 +1,6 @@
+DELETE FROM user_tokens WHERE expires_at IS NULL;
+ALTER TABLE user_tokens ALTER COLUMN expires_at SET NOT NULL;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_enabled,
+    DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_secret TEXT,
+    ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Recovery codes are stored as user tokens that never expire.
+ALTER TABLE user_tokens ALTER COLUMN expires_at DROP NOT NULL;
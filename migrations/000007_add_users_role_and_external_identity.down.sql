@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_users_external_identity;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS external_subject,
+    DROP COLUMN IF EXISTS auth_provider,
+    DROP COLUMN IF EXISTS role;
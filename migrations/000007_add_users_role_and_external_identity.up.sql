@@ -0,0 +1,8 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'customer',
+    ADD COLUMN IF NOT EXISTS auth_provider TEXT,
+    ADD COLUMN IF NOT EXISTS external_subject TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_external_identity
+    ON users(auth_provider, external_subject)
+    WHERE external_subject IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Usernames and emails are unique regardless of case. Existing duplicates
+-- have to be resolved by hand before this migration can run.
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_lower_key ON users (LOWER(username));
+
+-- Empty emails are allowed for accounts created without one.
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (LOWER(email)) WHERE email <> '';
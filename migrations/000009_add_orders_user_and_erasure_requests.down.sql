@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS erasure_requests;
+
+DROP INDEX IF EXISTS idx_orders_user_id;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,4 @@
+-- The time step of the last accepted TOTP code, so that a code can't be
+-- replayed within its validity window.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;
@@ -0,0 +1,5 @@
+-- Erasure renames a user to "erased-<id>", so only erased users may have a
+-- name with that prefix. Rename any other user holding one before migrating.
+ALTER TABLE users
+    ADD CONSTRAINT users_username_not_reserved
+    CHECK (user_status = 2 OR LOWER(username) NOT LIKE 'erased-%');
@@ -0,0 +1,4 @@
+-- Access tokens carry the version they were issued with; raising it, e.g. on
+-- a password reset, revokes every token issued before.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS token_version BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL schema migrations, so the binary doesn't
+// depend on the working directory it is started from.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS